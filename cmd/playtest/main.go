@@ -0,0 +1,113 @@
+// Command playtest lets the runner bot play every chapter headless over many seeds and reports
+// catch times, shots fired and failure rates, so a balance change can be checked without playing.
+//
+//	go run ./cmd/playtest -runs 50
+//
+// It exits non-zero when some chapter was never beaten.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/sim"
+	"beautifulmess/pkg/systems"
+)
+
+func main() {
+	runs := flag.Int("runs", 20, "seeded runs per chapter")
+	seed := flag.Int64("seed", 1, "seed of the first run; run n uses seed+n")
+	seconds := flag.Float64("seconds", 90, "sim time before a run counts as a failure")
+	chapter := flag.Int("level", 0, "only play this chapter (1-based); 0 plays them all")
+	dir := flag.String("dir", ".", "game directory holding the scripts, behaviours/ and levels/")
+	easy := flag.Bool("easy", false, "play with easy mode's homing bullets")
+	asJSON := flag.Bool("json", false, "print the reports as JSON instead of a table")
+	cfg := sim.Config{}
+	flag.Func("spectre-ai", "spectre brain: auto, script or native", func(s string) error {
+		d, ok := components.ParseAIDriver(s)
+		if !ok { return fmt.Errorf("want auto, script or native") }
+		cfg.SpectreAI = d
+		return nil
+	})
+	flag.Parse()
+	cfg.Seconds, cfg.EasyMode = *seconds, *easy
+
+	systems.ScriptDir = *dir
+	systems.TreeDir = *dir + "/behaviours"
+	levels, err := level.LoadLevels(*dir+"/levels", 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		levels = level.InitLevels(0)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	if err := systems.CheckPersonalities(levels); err != nil { log.Print(err) }
+
+	var reports []sim.Report
+	for i, lvl := range levels {
+		if *chapter != 0 && *chapter != i+1 { continue }
+		rep := sim.PlaytestLevel(lvl, i, *runs, *seed, cfg)
+		if rep.FirstErr != nil {
+			log.Printf("chapter %d: %d runs hit script errors and %d went over budget, first: %v", i+1, rep.Errors, rep.OverBudget, rep.FirstErr)
+		}
+		reports = append(reports, rep)
+	}
+
+	if *asJSON {
+		printJSON(reports)
+	} else {
+		printTable(reports)
+	}
+	for _, rep := range reports {
+		// Even one overrun means a script needs work, however often the level was beaten
+		if rep.OverBudget > 0 {
+			log.Printf("chapter %d (%s): %d of %d runs went over the script budget", rep.Chapter+1, rep.Level, rep.OverBudget, rep.Runs)
+			os.Exit(1)
+		}
+		if rep.Catches == 0 {
+			log.Printf("chapter %d (%s) was never beaten", rep.Chapter+1, rep.Level)
+			os.Exit(1)
+		}
+	}
+}
+
+func printTable(reports []sim.Report) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "chapter\tlevel\truns\tfail%\tmean s\tp50 s\tp90 s\tshots/run\thits/run\terrors\tover budget\t")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%.0f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%d\t%d\t\n",
+			r.Chapter+1, r.Level, r.Runs, r.FailureRate()*100, r.MeanCatch(), r.CatchPercentile(0.5), r.CatchPercentile(0.9), r.ShotsPerRun(), r.HitsPerRun(), r.Errors, r.OverBudget)
+	}
+	tw.Flush()
+}
+
+type jsonReport struct {
+	Chapter     int       `json:"chapter"`
+	Level       string    `json:"level"`
+	Runs        int       `json:"runs"`
+	Catches     int       `json:"catches"`
+	Errors      int       `json:"errors"`
+	OverBudget  int       `json:"over_budget"`
+	FailureRate float64   `json:"failure_rate"`
+	MeanCatch   float64   `json:"mean_catch_seconds"`
+	ShotsPerRun float64   `json:"shots_per_run"`
+	HitsPerRun  float64   `json:"hits_per_run"`
+	Catch       []float64 `json:"catch_seconds"`
+}
+
+func printJSON(reports []sim.Report) {
+	out := make([]jsonReport, len(reports))
+	for i, r := range reports {
+		out[i] = jsonReport{r.Chapter + 1, r.Level, r.Runs, r.Catches, r.Errors, r.OverBudget, r.FailureRate(), r.MeanCatch(), r.ShotsPerRun(), r.HitsPerRun(), r.Catch}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil { log.Fatal(err) }
+}
@@ -0,0 +1,193 @@
+// Command simulate plays batches of headless games with the runner bot while sweeping tuning
+// parameters, and writes the time-to-catch distribution of every combination as CSV or JSON.
+//
+//	go run ./cmd/simulate -runs 50 -sweep spectre.max_speed=4:8:1 -sweep well.mass=1,2 -out sweep.csv
+//
+// Run with -params to list what can be swept. Games are played one at a time unless -workers asks
+// for more; script budgets count instructions, not time, so the worker count never changes results.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/sim"
+	"beautifulmess/pkg/systems"
+)
+
+// percentiles reported for the catch-time distribution
+var percentiles = []float64{0.1, 0.25, 0.5, 0.75, 0.9}
+
+func main() {
+	runs := flag.Int("runs", 20, "seeded runs per chapter and parameter combination")
+	seed := flag.Int64("seed", 1, "seed of the first run; run n uses seed+n, the same for every combination")
+	seconds := flag.Float64("seconds", 90, "sim time before a run counts as a failure")
+	chapter := flag.Int("level", 0, "only play this chapter (1-based); 0 plays them all")
+	dir := flag.String("dir", ".", "game directory holding the scripts, behaviours/ and levels/")
+	script := flag.String("spectre", "", "spectre script to run instead of spectre.lua, relative to -dir")
+	easy := flag.Bool("easy", false, "play with easy mode's homing bullets")
+	format := flag.String("format", "", "csv or json; defaults to the -out extension, else csv")
+	out := flag.String("out", "", "file to write; stdout when empty")
+	workers := flag.Int("workers", 1, "games played in parallel; results are the same for any count")
+	listParams := flag.Bool("params", false, "list the parameters -sweep accepts and exit")
+	var sweeps []sim.Sweep
+	flag.Func("sweep", "name=v1,v2,... or name=from:to:step; repeat to sweep several parameters together", func(s string) error {
+		sw, err := sim.ParseSweep(s)
+		sweeps = append(sweeps, sw)
+		return err
+	})
+	base := sim.Config{}
+	flag.Func("spectre-ai", "spectre brain: auto, script or native", func(s string) error {
+		d, ok := components.ParseAIDriver(s)
+		if !ok { return fmt.Errorf("want auto, script or native") }
+		base.SpectreAI = d
+		return nil
+	})
+	flag.Parse()
+
+	if *listParams {
+		for _, n := range sim.ParamNames() {
+			fmt.Printf("%-18s %s\n", n, sim.ParamDoc(n))
+		}
+		return
+	}
+	if *format == "" {
+		*format = "csv"
+		if filepath.Ext(*out) == ".json" { *format = "json" }
+	}
+	if *format != "csv" && *format != "json" { log.Fatalf("unknown format %q", *format) }
+	base.Seconds, base.EasyMode, base.SpectreScript = *seconds, *easy, *script
+
+	systems.ScriptDir = *dir
+	systems.TreeDir = filepath.Join(*dir, "behaviours")
+	levels, err := level.LoadLevels(filepath.Join(*dir, "levels"), 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		levels = level.InitLevels(0)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	if err := systems.CheckPersonalities(levels); err != nil { log.Print(err) }
+
+	type job struct {
+		chapter int
+		params  sim.Params
+	}
+	var jobs []job
+	for i := range levels {
+		if *chapter != 0 && *chapter != i+1 { continue }
+		for _, p := range sim.Grid(sweeps) {
+			jobs = append(jobs, job{i, p})
+		}
+	}
+	log.Printf("playing %d combinations x %d runs on %d workers", len(jobs), *runs, *workers)
+
+	reports := make([]sim.Report, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < max(*workers, 1); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				cfg := base
+				cfg.Params = jobs[i].params
+				reports[i] = sim.PlaytestLevel(levels[jobs[i].chapter], jobs[i].chapter, *runs, *seed, cfg)
+				if err := reports[i].FirstErr; err != nil {
+					log.Printf("chapter %d %v: %d runs hit script errors and %d went over budget, first: %v", jobs[i].chapter+1, jobs[i].params, reports[i].Errors, reports[i].OverBudget, err)
+				}
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil { log.Fatal(err) }
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		err = writeJSON(w, reports)
+	} else {
+		err = writeCSV(w, reports, sweeps)
+	}
+	if err != nil { log.Fatal(err) }
+}
+
+func writeCSV(w io.Writer, reports []sim.Report, sweeps []sim.Sweep) error {
+	cw := csv.NewWriter(w)
+	header := []string{"chapter", "level"}
+	for _, sw := range sweeps {
+		header = append(header, sw.Name)
+	}
+	header = append(header, "runs", "catches", "errors", "over_budget", "failure_rate", "mean_s")
+	for _, p := range percentiles {
+		header = append(header, fmt.Sprintf("p%.0f_s", p*100))
+	}
+	header = append(header, "shots_per_run")
+	if err := cw.Write(header); err != nil { return err }
+
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, r := range reports {
+		row := []string{strconv.Itoa(r.Chapter + 1), r.Level}
+		for _, sw := range sweeps {
+			row = append(row, strconv.FormatFloat(r.Params[sw.Name], 'g', -1, 64))
+		}
+		row = append(row, strconv.Itoa(r.Runs), strconv.Itoa(r.Catches), strconv.Itoa(r.Errors), strconv.Itoa(r.OverBudget), num(r.FailureRate()), num(r.MeanCatch()))
+		for _, p := range percentiles {
+			row = append(row, num(r.CatchPercentile(p)))
+		}
+		row = append(row, num(r.ShotsPerRun()))
+		if err := cw.Write(row); err != nil { return err }
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonReport struct {
+	Chapter     int                `json:"chapter"`
+	Level       string             `json:"level"`
+	Params      sim.Params         `json:"params"`
+	Runs        int                `json:"runs"`
+	Catches     int                `json:"catches"`
+	Errors      int                `json:"errors"`
+	OverBudget  int                `json:"over_budget"` // Runs a script overran; also counted in FailureRate
+	FailureRate float64            `json:"failure_rate"`
+	MeanCatch   float64            `json:"mean_catch_seconds"`
+	Percentiles map[string]float64 `json:"catch_percentiles"`
+	ShotsPerRun float64            `json:"shots_per_run"`
+	Catch       []float64          `json:"catch_seconds"` // Every successful run, ascending
+}
+
+func writeJSON(w io.Writer, reports []sim.Report) error {
+	out := make([]jsonReport, len(reports))
+	for i, r := range reports {
+		pct := make(map[string]float64, len(percentiles))
+		for _, p := range percentiles {
+			pct[fmt.Sprintf("p%.0f", p*100)] = r.CatchPercentile(p)
+		}
+		params := r.Params
+		if params == nil { params = sim.Params{} }
+		out[i] = jsonReport{r.Chapter + 1, r.Level, params, r.Runs, r.Catches, r.Errors, r.OverBudget, r.FailureRate(), r.MeanCatch(), pct, r.ShotsPerRun(), r.Catch}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
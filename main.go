@@ -1,10 +1,13 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"image"
 	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
+	"io/fs"
 	"log"
 	"math"
 	"math/rand"
@@ -12,9 +15,14 @@ import (
 	"fmt"
 	"strings"
 
+	"beautifulmess/pkg/audio"
 	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/input"
 	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/render"
+	"beautifulmess/pkg/replay"
+	"beautifulmess/pkg/sim"
 	"beautifulmess/pkg/systems"
 	"beautifulmess/pkg/world"
 
@@ -54,6 +62,7 @@ const (
 	StatePaused
 	StateTransitioning
 	StateEnding
+	StateControls
 )
 
 type Game struct {
@@ -80,7 +89,7 @@ type Game struct {
 	ShaderOptions ebiten.DrawRectShaderOptions 
 	PopupRNG      *rand.Rand
 	PhotoCache    map[string]*ebiten.Image
-	SpectreSprites map[string]*ebiten.Image
+	SpectreSprites map[string]components.Sprite
 	SpectreState   systems.SpectreVisualState
 	SpriteRunner  *ebiten.Image
 	StartTime time.Time
@@ -89,17 +98,71 @@ type Game struct {
 	StartAnimation float64
 	TypewriterChars int
 	MusicFade      float64
+	Controls       *input.Mapper    // Every key check goes through here so players can rebind actions
+	ControlsPath   string
+	ControlsIndex  int
+	Rebinding      bool             // Waiting for the key to assign to the selected action
+	Scripts        *systems.ScriptWatcher
+	ScriptErrors   *systems.ScriptDiagnostics
+	ShowDevOverlay bool
+	DebugEntity    core.Entity      // Whose behaviour tree the dev overlay follows
+	ScriptNotice   string  // Outcome of the last hot reload, shown briefly over any screen
+	NoticeTimer    float64
+	LiveInput      world.Input      // The human's device, restored after a replay
+	Recorder       *replay.Recorder // Captures the level in progress; nil while watching a replay
+	Replay         *replay.Player   // Non-nil while watching a replay
+	ReplayPath     string
+	SpectreAI      components.AIDriver
+	savedLevels    []level.Level // Campaign state parked while a replay overrides it
+	savedEasy      bool
 }
 
-func NewGame() *Game {
+// Options carries command-line overrides into the game
+type Options struct {
+	Seed       int64  // Non-zero pins every level's procedural layout for reproducible bug reports
+	ReplayPath string // Where level attempts are recorded and where "watch replay" reads from
+	ControlsPath string // Saved key bindings
+	StrictLua    bool   // Abort the level on the first script error instead of carrying on
+	SpectreAI    components.AIDriver // Script, native Go, or the script with the native fallback
+}
+
+// loadLevels reads the chapter files, falling back to the built-in set when the data directory is absent
+func loadLevels(seed int64) ([]level.Level, error) {
+	levels, err := level.LoadLevels("levels", seed)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Running without the data directory still yields a playable build
+		log.Printf("levels directory not found, using built-in chapters")
+		levels, err = level.InitLevels(seed), nil
+	}
+	if err != nil { return nil, err }
+	// A chapter with a misspelt personality still plays, with the default spectre
+	if err := systems.CheckPersonalities(levels); err != nil { log.Print(err) }
+	return levels, nil
+}
+
+func NewGame(opts Options) *Game {
 	rand.Seed(time.Now().UnixNano())
 	s, err := ebiten.NewShader(shaderNebula)
 	if err != nil { log.Fatal(err) }
+	levels, err := loadLevels(opts.Seed)
+	if err != nil { log.Fatal(err) }
+	controls := input.NewMapper()
+	if err := controls.Load(opts.ControlsPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("using default controls: %v", err)
+		controls = input.NewMapper()
+	}
 	g := &Game{
-		World:         world.NewWorld(),
+		World:         world.NewWorld(audio.NewAudioSystem(), render.Graphics{}, controls),
+		Controls:      controls,
+		ControlsPath:  opts.ControlsPath,
+		LiveInput:     controls,
+		ReplayPath:    opts.ReplayPath,
+		SpectreAI:     opts.SpectreAI,
+		Scripts:       systems.NewScriptWatcher("."),
+		ScriptErrors:  systems.NewScriptDiagnostics(opts.StrictLua),
 		State:         StateTitle,
 		MasterVolume:  0.5,
-		Levels:        level.InitLevels(),
+		Levels:        levels,
 		FrostMask:     image.NewRGBA(image.Rect(0, 0, core.MistWidth, core.MistHeight)),
 		NebulaShader:  s,
 		ShaderOptions: ebiten.DrawRectShaderOptions{Uniforms: make(map[string]interface{})},
@@ -109,7 +172,7 @@ func NewGame() *Game {
 		MusicFade:     1.0,
 		SpectreState:  systems.SpectreVisualState{State: "normal"},
 	}
-	g.SpectreSprites = systems.LoadSpectreSet("assets/normal.png", "assets/angy.png", "assets/kewt.png")
+	g.SpectreSprites = render.LoadSpectreSet("assets/normal.png", "assets/angy.png", "assets/kewt.png")
 	g.SpriteRunner = generateAstroSprite()
 	g.World.Audio.LoadFile("shoot", "assets/shoot.wav")
 	g.World.Audio.LoadFile("boom", "assets/boom.wav")
@@ -121,6 +184,8 @@ func NewGame() *Game {
 	}
 	g.FrostImg = ebiten.NewImageFromImage(g.FrostMask)
 	systems.InitLua(g.World)
+	systems.SubscribeFeedback(g.World)
+	world.Subscribe(g.World, g.reunite)
 	g.LoadLevel(0)
 	return g
 }
@@ -129,63 +194,101 @@ func (g *Game) LoadLevel(idx int) {
 	if idx >= len(g.Levels) { idx = len(g.Levels) - 1 }
 	g.CurrentLevel = idx
 	lvl := g.Levels[idx]
+	// Logging the seed lets any run be replayed exactly with -seed
+	log.Printf("loading level %d (%s) with seed %d", idx+1, lvl.Name, lvl.Seed)
+	simSeed := rand.Int63()
+	if g.Replay != nil { simSeed = g.Replay.Replay.SimSeed }
 	g.World.Reset()
+	g.World.Reseed(simSeed)
 	g.World.Particles.Reset()
+	// Fresh script state per level means a replay starts from exactly what the recording saw
+	if err := systems.LoadScripts(g.World, g.ScriptErrors); err != nil {
+		g.abortLevel(err)
+		return
+	}
 	g.spawnLevelEntities(lvl)
+	g.startRecording(idx, lvl.Seed, simSeed)
+}
+
+func (g *Game) startRecording(idx int, levelSeed, simSeed int64) {
+	if g.Replay != nil { return }
+	g.Recorder = replay.NewRecorder(g.LiveInput, &replay.Replay{
+		Version: replay.Version, Level: idx, LevelSeed: levelSeed, SimSeed: simSeed, EasyMode: g.EasyMode,
+	})
+	g.World.Input = g.Recorder
+}
+
+// saveRecording writes out the attempt in progress; it is called when a level ends either way
+func (g *Game) saveRecording() {
+	rec := g.Recorder
+	if rec == nil { return }
+	g.Recorder = nil
+	g.World.Input = g.LiveInput
+	if rec.Replay.Ticks() == 0 { return }
+	if err := replay.Save(g.ReplayPath, rec.Replay); err != nil {
+		log.Printf("saving replay: %v", err)
+		return
+	}
+	log.Printf("saved %d-tick replay of level %d to %s", rec.Replay.Ticks(), rec.Replay.Level+1, g.ReplayPath)
+}
+
+func (g *Game) watchReplay() {
+	rep, err := replay.Load(g.ReplayPath)
+	if err != nil {
+		log.Printf("cannot watch replay: %v", err)
+		return
+	}
+	levels, err := loadLevels(rep.LevelSeed)
+	if err != nil || rep.Level < 0 || rep.Level >= len(levels) {
+		log.Printf("cannot watch replay: level %d unavailable (%v)", rep.Level+1, err)
+		return
+	}
+	g.savedLevels, g.savedEasy = g.Levels, g.EasyMode
+	g.Levels, g.EasyMode = levels, rep.EasyMode
+	g.Replay = replay.NewPlayer(rep)
+	g.World.Input = g.Replay
+	g.State = StatePlaying
+	g.LoadLevel(rep.Level)
+	if rep.Intro { g.triggerSpitOut() }
+}
+
+func (g *Game) stopReplay() {
+	if g.Replay == nil { return }
+	g.Levels, g.EasyMode = g.savedLevels, g.savedEasy
+	g.Replay, g.savedLevels = nil, nil
+	g.World.Input = g.LiveInput
+}
+
+// abortLevel is strict mode's response to a script error: stop at once and show what went wrong
+func (g *Game) abortLevel(err error) {
+	log.Printf("strict lua: aborting level: %v", err)
+	g.ShowDevOverlay = true
+	g.returnToTitle()
+}
+
+// returnToTitle abandons the run in progress, keeping its recording and undoing any replay overrides
+func (g *Game) returnToTitle() {
+	g.saveRecording()
+	g.stopReplay()
+	g.State = StateTitle
+	g.TitleTimer = 0
+	g.TypewriterChars = 0
+	g.Popup = nil
 }
 
 func (g *Game) spawnLevelEntities(lvl level.Level) {
 	w := g.World
-	for _, well := range lvl.Wells {
-		id := w.CreateEntity()
-		w.Transforms[id] = &components.Transform{Position: well.Position}
-		w.GravityWells[id] = &components.GravityWell{Radius: well.Radius, Mass: well.Mass}
-		w.Tags[id] = &components.Tag{Name: "gravity_well"}
-	}
-	for _, wall := range lvl.Walls { spawnWall(w, wall.X, wall.Y, wall.Destructible) }
-	
-	// Friction and Mass scaling for "twists"
-	fric := lvl.Friction
-	if fric == 0 { fric = 0.94 }
-	mass := 1.0
-	// Level 4 "Explosive Chaos" twist: Higher mass to plow through walls
-	if g.CurrentLevel == 3 { mass = 5.0 }
-
-	g.SpectreID = w.CreateEntity()
-	w.Tags[g.SpectreID] = &components.Tag{Name: "spectre"}
-	w.Transforms[g.SpectreID] = &components.Transform{Position: lvl.StartP2}
-	w.Physics[g.SpectreID] = &components.Physics{MaxSpeed: 6.0, Friction: fric, Mass: mass, GravityMultiplier: 3.5}
-	
+	// The simulation side is shared with the headless tools; only the looks are added here
+	g.SpectreID, g.RunnerID = sim.Spawn(w, lvl, g.CurrentLevel, g.SpectreAI)
+	g.DebugEntity = g.SpectreID
+
 	// Dynamic scaling to maintain photo integrity while fitting the world
-	specW, _ := g.SpectreSprites["normal"].Size()
+	specW := g.SpectreSprites["normal"].Bounds().Dx()
 	sScale := 80.0 / float64(specW)
 	if sScale > 1.5 { sScale = 1.5 }
 	
-	w.Renders[g.SpectreID] = &components.Render{Sprite: g.SpectreSprites["normal"], Color: color.RGBA{255, 255, 255, 255}, Glow: true, Scale: sScale}
-	w.AIs[g.SpectreID] = &components.AI{ScriptName: "spectre.lua"}
-	
-	g.RunnerID = w.CreateEntity()
-	w.Tags[g.RunnerID] = &components.Tag{Name: "runner"}
-	w.Transforms[g.RunnerID] = &components.Transform{Position: lvl.StartP1}
-	w.Physics[g.RunnerID] = &components.Physics{MaxSpeed: 7.5, Friction: fric - 0.02, Mass: mass}
-	w.Renders[g.RunnerID] = &components.Render{Sprite: g.SpriteRunner, Color: color.RGBA{0, 255, 255, 255}, Glow: true, Scale: 1.0}
-	w.AIs[g.RunnerID] = &components.AI{ScriptName: "runner.lua"}
-	w.InputControlleds[g.RunnerID] = &components.InputControlled{}
-	w.ProjectileEmitters[g.RunnerID] = &components.ProjectileEmitter{Interval: 1.0}
-	w.AIs[g.SpectreID].TargetID = int(g.RunnerID)
-	w.AIs[g.RunnerID].TargetID = int(g.SpectreID)
-}
-
-func spawnWall(w *world.World, x, y float64, destructible bool) {
-	id := w.CreateEntity()
-	w.AddToActiveWalls(id)
-	w.Transforms[id] = &components.Transform{Position: core.Vector2{X: x, Y: y}}
-	w.Walls[id] = &components.Wall{Size: 10, Destructible: destructible}
-	img := ebiten.NewImage(10, 10)
-	c := color.RGBA{0, 255, 255, 255}
-	if destructible { c = color.RGBA{255, 150, 50, 255} }
-	img.Fill(c)
-	w.Renders[id] = &components.Render{Sprite: img, Color: color.RGBA{255, 255, 255, 255}, Scale: 1.0}
+	w.Renders.Add(g.SpectreID, &components.Render{Sprite: g.SpectreSprites["normal"], Color: color.RGBA{255, 255, 255, 255}, Glow: true, Scale: sScale, Layer: components.LayerShips})
+	w.Renders.Add(g.RunnerID, &components.Render{Sprite: g.SpriteRunner, Color: color.RGBA{0, 255, 255, 255}, Glow: true, Scale: 1.0, Layer: components.LayerShips})
 }
 
 func generateGothicSprite() *ebiten.Image {
@@ -223,11 +326,8 @@ func (g *Game) updateMusic() {
 	} else if g.State == StateEnding {
 		g.MusicFade = 1.0
 		// Manual loop check if music stops
-		if pool, ok := g.World.Audio.Pools["transition"]; ok {
-			if !pool[0].IsPlaying() {
-				pool[0].Rewind()
-				pool[0].Play()
-			}
+		if !g.World.Audio.IsPlaying("transition") {
+			g.World.Audio.PlayAt("transition", 0)
 		}
 	} else if g.State == StateTitle && g.MusicFade < 1.0 {
 		// Fade out completely if we just returned to title from game/ending
@@ -240,6 +340,8 @@ func (g *Game) updateMusic() {
 }
 
 func (g *Game) Update() error {
+	g.Controls.Update()
+	g.reloadScripts()
 	g.handleInput()
 	g.updateMusic()
 
@@ -254,10 +356,25 @@ func (g *Game) Update() error {
 		return g.updateTransitionState()
 	case StateEnding:
 		return g.updateEndingState()
+	case StateControls:
+		return g.updateControlsState()
 	}
 	return nil
 }
 
+func (g *Game) reloadScripts() {
+	for _, r := range g.Scripts.Poll(g.World) {
+		log.Printf("hot reload: %s", r)
+		g.ScriptNotice, g.NoticeTimer = r.String(), 4.0
+		if r.Err == nil {
+			g.ScriptErrors.ClearScript(r.Script)
+		} else {
+			g.ScriptErrors.Report(&systems.ScriptError{Script: r.Script, Entity: systems.NoEntity, Tick: g.World.Tick, Message: r.Err.Error(), Count: 1})
+		}
+	}
+	if g.NoticeTimer > 0 { g.NoticeTimer -= 1.0 / 60.0 }
+}
+
 func (g *Game) updateTitleState() error {
 	g.TitleTimer += 1.0 / 60.0
 
@@ -284,22 +401,22 @@ func (g *Game) updateTitleState() error {
 	}
 
 	// Menu Navigation
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) || inpututil.IsKeyJustPressed(ebiten.KeyW) {
-		g.MenuIndex = (g.MenuIndex - 1 + 5) % 5
+	if g.Controls.JustPressed(input.ActionMoveUp) {
+		g.MenuIndex = (g.MenuIndex - 1 + 7) % 7
 		g.World.Audio.Play("blip")
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) || inpututil.IsKeyJustPressed(ebiten.KeyS) {
-		g.MenuIndex = (g.MenuIndex + 1) % 5
+	if g.Controls.JustPressed(input.ActionMoveDown) {
+		g.MenuIndex = (g.MenuIndex + 1) % 7
 		g.World.Audio.Play("blip")
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) || inpututil.IsKeyJustPressed(ebiten.KeyA) {
+	if g.Controls.JustPressed(input.ActionMoveLeft) {
 		if g.MenuIndex == 2 { // Volume adjustment
 			g.MasterVolume = math.Max(0, g.MasterVolume-0.05)
 			g.World.Audio.SetVolume(g.MasterVolume)
 			g.World.Audio.Play("blip")
 		}
 	}
-	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) || inpututil.IsKeyJustPressed(ebiten.KeyD) {
+	if g.Controls.JustPressed(input.ActionMoveRight) {
 		if g.MenuIndex == 2 { // Volume adjustment
 			g.MasterVolume = math.Min(1.0, g.MasterVolume+0.05)
 			g.World.Audio.SetVolume(g.MasterVolume)
@@ -307,7 +424,7 @@ func (g *Game) updateTitleState() error {
 		}
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+	if g.Controls.JustPressed(input.ActionConfirm) {
 		switch g.MenuIndex {
 		case 0: // Start
 			g.State = StatePlaying
@@ -321,17 +438,66 @@ func (g *Game) updateTitleState() error {
 		case 3: // Display toggle
 			ebiten.SetFullscreen(!ebiten.IsFullscreen())
 			g.World.Audio.Play("blip")
-		case 4: // Exit
+		case 4: // Key bindings
+			g.State, g.ControlsIndex, g.Rebinding = StateControls, 0, false
+			g.World.Audio.Play("blip")
+		case 5: // Watch the last recorded attempt
+			g.watchReplay()
+			g.World.Audio.Play("blip")
+		case 6: // Exit
 			return ebiten.Termination
 		}
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+	if g.Controls.JustPressed(input.ActionBack) {
 		return ebiten.Termination
 	}
 	return nil
 }
 
+func (g *Game) updateControlsState() error {
+	m := g.Controls
+	if g.Rebinding {
+		// Escape is hardwired here so a bad binding can never trap the player in this screen
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.Rebinding = false
+			return nil
+		}
+		if keys := inpututil.AppendJustPressedKeys(nil); len(keys) > 0 {
+			m.Rebind(input.Action(g.ControlsIndex), keys[0])
+			g.Rebinding = false
+			g.World.Audio.Play("blip")
+		}
+		return nil
+	}
+
+	rows := int(input.NumActions) + 1 // The extra row restores the defaults
+	if m.JustPressed(input.ActionMoveUp) {
+		g.ControlsIndex = (g.ControlsIndex - 1 + rows) % rows
+		g.World.Audio.Play("blip")
+	}
+	if m.JustPressed(input.ActionMoveDown) {
+		g.ControlsIndex = (g.ControlsIndex + 1) % rows
+		g.World.Audio.Play("blip")
+	}
+	if m.JustPressed(input.ActionConfirm) {
+		if g.ControlsIndex == int(input.NumActions) {
+			m.Bindings = input.DefaultBindings()
+			g.World.Audio.Play("chime")
+		} else {
+			g.Rebinding = true
+		}
+	}
+	if m.JustPressed(input.ActionBack) {
+		if err := m.Save(g.ControlsPath); err != nil {
+			log.Printf("saving controls: %v", err)
+		}
+		g.State, g.TitleTimer = StateTitle, 3.0 // Skip the typewriter; the player has already seen it
+		g.World.Audio.Play("blip")
+	}
+	return nil
+}
+
 func (g *Game) triggerSpitOut() {
 	lvl := g.Levels[g.CurrentLevel]
 	wellPos := core.Vector2{X: 640, Y: 360}
@@ -340,39 +506,43 @@ func (g *Game) triggerSpitOut() {
 	}
 
 	// Reset positions to well center with slight offset to prevent immediate collision/win
-	if t := g.World.Transforms[g.RunnerID]; t != nil {
+	if t := g.World.Transforms.Get(g.RunnerID); t != nil {
 		t.Position = core.Vector2{X: wellPos.X - 5, Y: wellPos.Y}
 	}
-	if t := g.World.Transforms[g.SpectreID]; t != nil {
+	if t := g.World.Transforms.Get(g.SpectreID); t != nil {
 		t.Position = core.Vector2{X: wellPos.X + 5, Y: wellPos.Y}
 	}
 
 	// Explosive outward velocity - bypassing MaxSpeed in SystemPhysics
-	if p := g.World.Physics[g.RunnerID]; p != nil {
+	if p := g.World.Physics.Get(g.RunnerID); p != nil {
 		p.Velocity = core.Vector2{X: -35, Y: -15}
 	}
-	if p := g.World.Physics[g.SpectreID]; p != nil {
+	if p := g.World.Physics.Get(g.SpectreID); p != nil {
 		p.Velocity = core.Vector2{X: 35, Y: 15}
 	}
 
+	if g.Recorder != nil { g.Recorder.Replay.Intro = true }
 	g.StartAnimation = 1.5
 	g.World.ScreenShake = 15.0
 	g.World.Audio.Play("boom")
 }
 
 func (g *Game) handleInput() {
-	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+	if g.Controls.JustPressed(input.ActionDevOverlay) {
+		g.ShowDevOverlay = !g.ShowDevOverlay
+	}
+	if g.ShowDevOverlay && g.Controls.JustPressed(input.ActionDevSelect) {
+		g.selectNextTree()
+	}
+	if g.Controls.JustPressed(input.ActionFullscreen) {
 		ebiten.SetFullscreen(!ebiten.IsFullscreen())
 	}
 	
 	// ESC Logic
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+	if g.Controls.JustPressed(input.ActionBack) {
 		if g.Popup != nil || g.State == StatePaused || g.State == StateEnding {
 			// Start fading music if it's playing
-			g.State = StateTitle
-			g.TitleTimer = 0
-			g.TypewriterChars = 0
-			g.Popup = nil
+			g.returnToTitle()
 			return
 		} else if g.State == StatePlaying {
 			// If we're just playing, ESC pauses the game to show the menu
@@ -382,7 +552,7 @@ func (g *Game) handleInput() {
 	}
 
 	if (g.State == StatePlaying || g.State == StatePaused) && g.Popup == nil {
-		if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		if g.Controls.JustPressed(input.ActionPause) {
 			if g.State == StatePaused {
 				g.State = StatePlaying
 			} else {
@@ -427,18 +597,21 @@ func (g *Game) updatePausedState() error {
 			}
 		} else {
 			// Manual control restored after sequence finishes
-			if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) || inpututil.IsKeyJustPressed(ebiten.KeyD) {
+			if g.Controls.JustPressed(input.ActionNextPhoto) {
 				g.PopupPhotoIndex = (g.PopupPhotoIndex + 1) % len(g.Popup.Photos)
 				g.TypewriterChars = 9999 
 				g.World.Audio.Play("blip")
 			}
-			if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) || inpututil.IsKeyJustPressed(ebiten.KeyA) {
+			if g.Controls.JustPressed(input.ActionPrevPhoto) {
 				g.PopupPhotoIndex = (g.PopupPhotoIndex - 1 + len(g.Popup.Photos)) % len(g.Popup.Photos)
 				g.TypewriterChars = 9999
 				g.World.Audio.Play("blip")
 			}
-			if inpututil.IsKeyJustPressed(ebiten.KeySpace) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
-				if g.CurrentLevel >= len(g.Levels)-1 {
+			if g.Controls.JustPressed(input.ActionConfirm) {
+				if g.Replay != nil {
+					// A replay covers a single level, so there is nowhere further to go
+					g.returnToTitle()
+				} else if g.CurrentLevel >= len(g.Levels)-1 {
 					g.World.Audio.PlayAt("transition", 0) // Full song for ending
 					g.MusicFade = 1.0
 					g.State = StateEnding
@@ -453,10 +626,8 @@ func (g *Game) updatePausedState() error {
 			}
 		}
 	} else {
-		if inpututil.IsKeyJustPressed(ebiten.KeyM) {
-			g.State = StateTitle
-			g.TitleTimer = 0
-			g.TypewriterChars = 0
+		if g.Controls.JustPressed(input.ActionMenu) {
+			g.returnToTitle()
 		}
 	}
 	return nil
@@ -464,10 +635,8 @@ func (g *Game) updatePausedState() error {
 
 func (g *Game) updateEndingState() error {
 	g.TransitionTime += 1.0 / 60.0
-	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || (g.TransitionTime > 10 && inpututil.IsKeyJustPressed(ebiten.KeySpace)) {
-		g.State = StateTitle
-		g.TitleTimer = 0
-		g.TypewriterChars = 0
+	if g.Controls.JustPressed(input.ActionBack) || (g.TransitionTime > 10 && g.Controls.JustPressed(input.ActionConfirm)) {
+		g.returnToTitle()
 	}
 	return nil
 }
@@ -506,6 +675,13 @@ func (g *Game) updateActiveState() error {
 		return nil
 	}
 
+	// The sim clock only advances on frames that actually simulate, so hit-stop and pause freeze it
+	g.World.Advance()
+	if g.Replay != nil && g.Replay.Done() {
+		g.returnToTitle()
+		return nil
+	}
+
 	g.World.ScreenShake *= 0.9
 	if g.World.ScreenShake < 0.5 {
 		g.World.ScreenShake = 0
@@ -520,41 +696,33 @@ func (g *Game) updateActiveState() error {
 		systems.SystemInput(g.World)
 	}
 
-	systems.SystemAI(g.World, lvl)
+	if err := systems.SystemAI(g.World, lvl, g.ScriptErrors); err != nil {
+		g.abortLevel(err)
+		return nil
+	}
 	systems.SystemSpectreVisuals(g.World, &g.SpectreState, g.SpectreID, g.SpectreSprites)
 	systems.SystemPhysics(g.World, g.EasyMode, g.StartAnimation > 0)
 	systems.SystemEntropy(g.World, g.FrostMask)
 	systems.SystemProjectileEmitter(g.World)
 	systems.SystemLifetime(g.World)
 
-	g.World.Particles.Update()
-
 	if g.StartAnimation <= 0 {
-		return g.checkWinCondition(lvl)
+		sim.CheckCatch(g.World, g.SpectreID, g.RunnerID)
 	}
+	g.World.Dispatch()
+	g.World.Particles.Update()
 	return nil
 }
 
-func (g *Game) checkWinCondition(lvl *level.Level) error {
-	pSpec, pRun := g.World.Transforms[g.SpectreID], g.World.Transforms[g.RunnerID]
-	if pSpec == nil || pRun == nil { return nil }
-	if core.DistWrapped(pSpec.Position, pRun.Position) < 80 {
-		for id, well := range g.World.GravityWells {
-			if well == nil { continue }
-			wellTrans := g.World.Transforms[id]
-			if wellTrans == nil { continue }
-			if core.DistWrapped(pSpec.Position, wellTrans.Position) < well.Radius+15 {
-				g.State, g.Popup, g.PopupTime, g.PopupPhotoIndex = StatePaused, &lvl.Memory, time.Now(), 0
-				g.PopupAutoMode = true
-				g.PopupWaitTimer = 0
-				g.TypewriterChars = 0
-				g.ReunionPoint = pSpec.Position
-				g.World.Audio.Play("chime")
-				return nil
-			}
-		}
-	}
-	return nil
+// reunite ends the level once the spectre is caught by opening the chapter's memory
+func (g *Game) reunite(e world.SpectreCaught) {
+	g.State, g.Popup, g.PopupTime, g.PopupPhotoIndex = StatePaused, &g.Levels[g.CurrentLevel].Memory, time.Now(), 0
+	g.PopupAutoMode = true
+	g.PopupWaitTimer = 0
+	g.TypewriterChars = 0
+	g.ReunionPoint = e.Position
+	g.World.Audio.Play("chime")
+	g.saveRecording()
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
@@ -563,6 +731,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		g.drawTitleScreen(screen)
 	case StateEnding:
 		g.drawEndingScreen(screen)
+	case StateControls:
+		g.drawControlsScreen(screen)
 	default:
 		shake := core.Vector2{}
 		if g.World.ScreenShake > 0 {
@@ -573,17 +743,79 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		if g.State == StateTransitioning { g.drawTransition(screen) }
 		g.drawUI(screen)
 	}
+	if g.NoticeTimer > 0 {
+		ebitenutil.DebugPrintAt(screen, g.ScriptNotice, 20, core.ScreenHeight-30)
+	}
+	g.drawDevOverlay(screen)
+}
+
+func (g *Game) drawDevOverlay(screen *ebiten.Image) {
+	errs := g.ScriptErrors.Recent
+	key := g.Controls.KeyLabel(input.ActionDevOverlay)
+	if !g.ShowDevOverlay {
+		// A quiet badge is enough to tell designers something broke without covering the game
+		if len(errs) > 0 {
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("LUA ERRORS: %d  [%s] DETAILS", len(errs), key), core.ScreenWidth-230, 20)
+		}
+		return
+	}
+
+	vector.DrawFilledRect(screen, 10, 10, core.ScreenWidth-20, 300, color.RGBA{0, 0, 0, 220}, false)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("--- LUA DIAGNOSTICS ---  STRICT: %v  [%s] HIDE", g.ScriptErrors.Strict, key), 20, 15)
+	ebitenutil.DebugPrintAt(screen, g.treeStatus(), 20, 35)
+	if len(errs) == 0 {
+		ebitenutil.DebugPrintAt(screen, "no script errors", 20, 60)
+		return
+	}
+	y := 60
+	for i := len(errs) - 1; i >= 0 && y < 290; i-- {
+		e := errs[i]
+		where := e.Script
+		if e.Entity != systems.NoEntity { where = fmt.Sprintf("%s #%d", e.Script, e.Entity) }
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("[tick %d] x%d %s: %s", e.Tick, e.Count, where, e.Message), 20, y)
+		y += 16
+		// The innermost frames are usually the ones that matter
+		trace := strings.Split(strings.TrimSpace(e.Trace), "\n")
+		for j := 1; j < len(trace) && j <= 2; j++ {
+			ebitenutil.DebugPrintAt(screen, "    "+strings.TrimSpace(trace[j]), 20, y)
+			y += 16
+		}
+	}
+}
+
+// treeStatus describes where the followed entity's behaviour tree is, e.g. "BT #1 spectre_wary: wary > evade > wait 0.25s (running)"
+func (g *Game) treeStatus() string {
+	sel := g.Controls.KeyLabel(input.ActionDevSelect)
+	w, id := g.World, g.DebugEntity
+	ai := w.AIs.Get(id)
+	if !w.Exists(id) || ai == nil || ai.Tree == nil {
+		return fmt.Sprintf("BT: entity %d runs no behaviour tree  [%s] NEXT", id, sel)
+	}
+	tree := ai.Tree
+	return fmt.Sprintf("BT #%d %s: %s (%v)  [%s] NEXT", id, tree.Name, tree.ActivePath(), tree.Last, sel)
+}
+
+// selectNextTree moves the dev overlay on to the next entity that is running a behaviour tree
+func (g *Game) selectNextTree() {
+	ais, slots := g.World.AIs, g.World.Slots()
+	for i := 1; i <= slots; i++ {
+		id := core.Entity((int(g.DebugEntity) + i) % slots)
+		if ai := ais.Get(id); ai != nil && ai.Tree != nil {
+			g.DebugEntity = id
+			return
+		}
+	}
 }
 
 func (g *Game) drawWorld(screen *ebiten.Image, shake core.Vector2) {
 	g.drawBackground(screen)
-	g.World.Particles.Draw(screen)
+	render.DrawParticles(screen, g.World.Particles)
 	lvl := &g.Levels[g.CurrentLevel]
 	spectrePos := core.Vector2{}
-	if trans := g.World.Transforms[g.SpectreID]; trans != nil { spectrePos = trans.Position }
-	systems.DrawLevel(screen, g.World, lvl, spectrePos, shake)
+	if trans := g.World.Transforms.Get(g.SpectreID); trans != nil { spectrePos = trans.Position }
+	render.DrawLevel(screen, g.World, lvl, spectrePos, shake)
 	g.drawMist(screen)
-	systems.DrawEntities(screen, g.World, shake)
+	render.DrawEntities(screen, g.World, shake)
 }
 
 func (g *Game) drawBackground(screen *ebiten.Image) {
@@ -604,6 +836,10 @@ func (g *Game) drawUI(screen *ebiten.Image) {
 	switch g.State {
 	case StateTitle:
 		g.drawTitleScreen(screen)
+	case StatePlaying:
+		if g.Replay != nil {
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("REPLAY  TICK %d / %d  [%s] PAUSE", g.World.Tick, g.Replay.Replay.Ticks(), g.Controls.KeyLabel(input.ActionBack)), 20, 20)
+		}
 	case StatePaused:
 		if g.Popup != nil {
 			g.drawPopup(screen)
@@ -649,6 +885,8 @@ func (g *Game) drawTitleScreen(screen *ebiten.Image) {
 			"MODE: NORMAL",
 			"VOLUME: [..........]",
 			"FULLSCREEN",
+			"CONTROLS",
+			"WATCH REPLAY",
 			"QUIT TO DESKTOP",
 		}
 		if g.EasyMode { options[1] = "MODE: EASY (HOMING)" }
@@ -662,7 +900,7 @@ func (g *Game) drawTitleScreen(screen *ebiten.Image) {
 		options[2] = "VOLUME: [" + bar + "]"
 
 		for i, opt := range options {
-			y := int(by) + 240 + (i * 35)
+			y := int(by) + 230 + (i * 27)
 			prefix := "  "
 			if g.MenuIndex == i {
 				prefix = "> "
@@ -675,8 +913,38 @@ func (g *Game) drawTitleScreen(screen *ebiten.Image) {
 			}
 		}
 		
-		ebitenutil.DebugPrintAt(screen, "(W/S) NAVIGATE  (A/D) ADJUST  (SPACE) SELECT", int(bx)+240, int(by)+430)
+		m := g.Controls
+		hint := fmt.Sprintf("(%s/%s) NAVIGATE  (%s/%s) ADJUST  (%s) SELECT",
+			m.KeyLabel(input.ActionMoveUp), m.KeyLabel(input.ActionMoveDown),
+			m.KeyLabel(input.ActionMoveLeft), m.KeyLabel(input.ActionMoveRight), m.KeyLabel(input.ActionConfirm))
+		ebitenutil.DebugPrintAt(screen, hint, int(bx)+(int(bw)-len(hint)*6)/2, int(by)+430)
+	}
+}
+
+func (g *Game) drawControlsScreen(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+
+	bw, bh := 860.0, 480.0
+	bx, by := (float64(core.ScreenWidth)-bw)/2, (float64(core.ScreenHeight)-bh)/2
+	vector.StrokeRect(screen, float32(bx), float32(by), float32(bw), float32(bh), 1, color.RGBA{255, 176, 0, 255}, false)
+	ebitenutil.DebugPrintAt(screen, "--- CONTROLS ---", int(bx)+380, int(by)+30)
+
+	for i := 0; i <= int(input.NumActions); i++ {
+		line := "RESET TO DEFAULTS"
+		if i < int(input.NumActions) {
+			a := input.Action(i)
+			keys := g.Controls.KeyLabel(a)
+			if g.Rebinding && g.ControlsIndex == i { keys = "PRESS A KEY..." }
+			line = fmt.Sprintf("%-14s %s", a.Label(), keys)
+		}
+		prefix := "  "
+		if g.ControlsIndex == i { prefix = "> " }
+		ebitenutil.DebugPrintAt(screen, prefix+line, int(bx)+300, int(by)+70+i*26)
 	}
+
+	hint := fmt.Sprintf("(%s) REBIND  (%s) SAVE AND RETURN  [ESC] CANCELS A REBIND",
+		g.Controls.KeyLabel(input.ActionConfirm), g.Controls.KeyLabel(input.ActionBack))
+	ebitenutil.DebugPrintAt(screen, hint, int(bx)+(int(bw)-len(hint)*6)/2, int(by)+440)
 }
 
 func (g *Game) drawVignette(screen *ebiten.Image) {
@@ -715,7 +983,7 @@ func (g *Game) drawPopup(screen *ebiten.Image) {
 
 func (g *Game) renderPopupContent(screen *ebiten.Image, bx, by, bw, bh float64) {
 	// 1. Top Navigation Bar
-	ebitenutil.DebugPrintAt(screen, "[ESC] QUIT TO TITLE", int(bx)+20, int(by)+15)
+	ebitenutil.DebugPrintAt(screen, "["+g.Controls.KeyLabel(input.ActionBack)+"] QUIT TO TITLE", int(bx)+20, int(by)+15)
 	ebitenutil.DebugPrintAt(screen, "--- MEMORY FRAGMENT ---", int(bx)+255, int(by)+15)
 
 	// 2. Metadata Section
@@ -758,8 +1026,9 @@ func (g *Game) renderPopupContent(screen *ebiten.Image, bx, by, bw, bh float64)
 
 	// 3. Side Navigation
 	if len(g.Popup.Photos) > 1 && !g.PopupAutoMode {
-		ebitenutil.DebugPrintAt(screen, "< [A] PREV", int(px)-90, int(py)+int(photoH)/2)
-		ebitenutil.DebugPrintAt(screen, "[D] NEXT >", int(px)+int(photoW)+10, int(py)+int(photoH)/2)
+		prev := "< [" + g.Controls.KeyLabel(input.ActionPrevPhoto) + "] PREV"
+		ebitenutil.DebugPrintAt(screen, prev, int(px)-10-len(prev)*6, int(py)+int(photoH)/2)
+		ebitenutil.DebugPrintAt(screen, "["+g.Controls.KeyLabel(input.ActionNextPhoto)+"] NEXT >", int(px)+int(photoW)+10, int(py)+int(photoH)/2)
 	}
 
 	// 4. Description Section
@@ -778,7 +1047,7 @@ func (g *Game) renderPopupContent(screen *ebiten.Image, bx, by, bw, bh float64)
 
 	// 5. Footer Prompts
 	if !g.PopupAutoMode {
-		ebitenutil.DebugPrintAt(screen, "[ SPACE ] RECOVER FRAGMENT", int(bx)+245, int(by)+int(bh)-25)
+		ebitenutil.DebugPrintAt(screen, "[ "+g.Controls.KeyLabel(input.ActionConfirm)+" ] RECOVER FRAGMENT", int(bx)+245, int(by)+int(bh)-25)
 	} else {
 		ebitenutil.DebugPrintAt(screen, "( STABILIZING MEMORY DATA... )", int(bx)+235, int(by)+int(bh)-25)
 	}
@@ -807,8 +1076,9 @@ func (g *Game) drawPauseMenu(screen *ebiten.Image) {
 	bx, by := (float64(core.ScreenWidth)-bw)/2, (float64(core.ScreenHeight)-bh)/2
 	vector.StrokeRect(screen, float32(bx), float32(by), float32(bw), float32(bh), 2, color.RGBA{33, 33, 255, 255}, false)
 	ebitenutil.DebugPrintAt(screen, "--- PAUSED ---", int(bx)+160, int(by)+30)
-	ebitenutil.DebugPrintAt(screen, "RESUME: PRESS P", int(bx)+160, int(by)+60)
-	ebitenutil.DebugPrintAt(screen, "RETURN TO MENU: PRESS M or ESC", int(bx)+100, int(by)+90)
+	ebitenutil.DebugPrintAt(screen, "RESUME: PRESS "+g.Controls.KeyLabel(input.ActionPause), int(bx)+160, int(by)+60)
+	ebitenutil.DebugPrintAt(screen, "RETURN TO MENU: PRESS "+g.Controls.KeyLabel(input.ActionMenu)+" or "+g.Controls.KeyLabel(input.ActionBack), int(bx)+100, int(by)+90)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("SEED: %d", g.Levels[g.CurrentLevel].Seed), int(bx)+20, int(by)+int(bh)-35)
 	for px := 0; px < 5; px++ {
 		vector.DrawFilledCircle(screen, float32(bx)+float32(px*90)+45, float32(by)+15, 2, color.RGBA{255, 255, 0, 255}, true)
 		vector.DrawFilledCircle(screen, float32(bx)+float32(px*90)+45, float32(by)+float32(bh)-15, 2, color.RGBA{255, 255, 0, 255}, true)
@@ -818,10 +1088,8 @@ func (g *Game) drawPauseMenu(screen *ebiten.Image) {
 func (g *Game) drawTransition(screen *ebiten.Image) {
 	t := g.TransitionTime
 	g.drawBloom(screen, g.ReunionPoint, 400.0*t, color.RGBA{255, 200, 255, uint8(255 * (1.0 - t))})
-	for id, wellValue := range g.World.GravityWells {
-		if wellValue == nil { continue }
-		wellTrans := g.World.Transforms[id]
-		if wellTrans == nil { continue }
+	for id := range world.Query(g.World.GravityWells, g.World.Transforms) {
+		wellValue, wellTrans := g.World.GravityWells.Get(id), g.World.Transforms.Get(id)
 		g.drawBloom(screen, wellTrans.Position, 300.0*t, color.RGBA{255, 255, 200, uint8(200 * (1.0 - t))})
 		coreAlpha := uint8(200 * t)
 		vector.DrawFilledCircle(screen, float32(wellTrans.Position.X), float32(wellTrans.Position.Y), float32(wellValue.Radius * (1.0 + t*2)), color.RGBA{255, 255, 255, coreAlpha}, true)
@@ -879,7 +1147,7 @@ func (g *Game) drawEndingScreen(screen *ebiten.Image) {
 	g.drawIsaacText(screen, s2, centerX, centerY+60, t+0.5)
 	
 	if t > 5 {
-		ebitenutil.DebugPrintAt(screen, "[ PRESS ESC TO RETURN ]", centerX-80, core.ScreenHeight-50)
+		ebitenutil.DebugPrintAt(screen, "[ PRESS "+g.Controls.KeyLabel(input.ActionBack)+" TO RETURN ]", centerX-80, core.ScreenHeight-50)
 	}
 }
 
@@ -950,8 +1218,21 @@ func (g *Game) drawCharColored(screen *ebiten.Image, char string, x, y float64,
 func (g *Game) Layout(w, h int) (int, int) { return core.ScreenWidth, core.ScreenHeight }
 
 func main() {
+	var opts Options
+	flag.Int64Var(&opts.Seed, "seed", 0, "force a procedural generation seed for every level (0 = per-level/random)")
+	flag.BoolVar(&opts.StrictLua, "strict-lua", false, "abort the level on the first Lua script error")
+	flag.StringVar(&opts.ControlsPath, "controls", "controls.json", "file the key bindings are loaded from and saved to")
+	flag.StringVar(&opts.ReplayPath, "replay", "replay.json", "file each level attempt is recorded to and \"watch replay\" plays back")
+	flag.Func("spectre-ai", "spectre brain: auto (script with native fallback), script or native", func(s string) error {
+		d, ok := components.ParseAIDriver(s)
+		if !ok { return fmt.Errorf("want auto, script or native") }
+		opts.SpectreAI = d
+		return nil
+	})
+	flag.Parse()
+
 	ebiten.SetWindowSize(core.ScreenWidth, core.ScreenHeight)
 	ebiten.SetWindowTitle("Beautiful Mess: The Final Code")
 	ebiten.SetFullscreen(true)
-	if err := ebiten.RunGame(NewGame()); err != nil { panic(err) }
+	if err := ebiten.RunGame(NewGame(opts)); err != nil { panic(err) }
 }
@@ -98,6 +98,13 @@ func (as *AudioSystem) PlayAt(name string, offset time.Duration) {
 	}
 }
 
+func (as *AudioSystem) IsPlaying(name string) bool {
+	for _, p := range as.Pools[name] {
+		if p.IsPlaying() { return true }
+	}
+	return false
+}
+
 func (as *AudioSystem) Stop(name string) {
 	if pool, ok := as.Pools[name]; ok {
 		for _, p := range pool {
@@ -0,0 +1,330 @@
+// Package bt is a small behaviour-tree runtime. Trees are built from data (see Spec) and ticked once per
+// simulation step; leaves are supplied by the host, which for the game means Lua functions.
+package bt
+
+import (
+	"strings"
+)
+
+type Status int
+
+const (
+	Failure Status = iota
+	Success
+	Running
+)
+
+var statusNames = [...]string{"failure", "success", "running"}
+
+func (s Status) String() string { return statusNames[s] }
+
+// Blackboard is the shared memory nodes read from. For scripted entities it is the script's self table.
+type Blackboard interface {
+	// Get returns nil, bool, float64 or string
+	Get(key string) any
+}
+
+// MapBlackboard is a plain Go blackboard for trees that are not driven by a script
+type MapBlackboard map[string]any
+
+func (m MapBlackboard) Get(key string) any { return m[key] }
+
+// Leaf is an action or condition supplied by the host
+type Leaf func() Status
+
+// Node is one step of a tree. Nodes keep their own progress, so every entity needs its own Tree.
+type Node interface {
+	Tick(t *Tree) Status
+	// Reset abandons any progress, e.g. when a higher-priority branch pre-empts this one
+	Reset()
+	Label() string
+}
+
+// Tree is an instance of a behaviour tree for one entity
+type Tree struct {
+	Name string
+	Root Node
+
+	Now        uint64 // Sim tick of the current Tick call
+	Blackboard Blackboard
+	Last       Status
+
+	path  []string
+	depth int
+}
+
+// Tick runs the tree once at sim tick now against bb
+func (t *Tree) Tick(now uint64, bb Blackboard) Status {
+	t.Now, t.Blackboard = now, bb
+	t.depth = 0
+	t.Last = t.tick(t.Root)
+	return t.Last
+}
+
+func (t *Tree) Reset() {
+	t.Root.Reset()
+	t.path = t.path[:0]
+}
+
+// Active is the branch the last Tick ended on, root first. A node replaces its earlier siblings in the
+// path as it runs, so after a selector falls through to its third child only that child is listed.
+func (t *Tree) Active() []string {
+	return t.path
+}
+
+// ActivePath formats Active for display, e.g. "root > evade > wait 0.25s"
+func (t *Tree) ActivePath() string {
+	return strings.Join(t.path, " > ")
+}
+
+func (t *Tree) tick(n Node) Status {
+	t.path = append(t.path[:t.depth], n.Label())
+	t.depth++
+	s := n.Tick(t)
+	t.depth--
+	return s
+}
+
+// sequence runs children in order until one fails. It remembers a running child and resumes there,
+// so the conditions that started a sequence are not re-checked halfway through it.
+type sequence struct {
+	label    string
+	children []Node
+	current  int
+}
+
+func (n *sequence) Label() string { return n.label }
+
+func (n *sequence) Tick(t *Tree) Status {
+	for ; n.current < len(n.children); n.current++ {
+		switch t.tick(n.children[n.current]) {
+		case Running:
+			return Running
+		case Failure:
+			n.Reset()
+			return Failure
+		}
+	}
+	n.Reset()
+	return Success
+}
+
+func (n *sequence) Reset() {
+	n.current = 0
+	for _, c := range n.children {
+		c.Reset()
+	}
+}
+
+// selector tries children in priority order every tick. A higher-priority child that stops failing
+// pre-empts a running lower one, which is reset.
+type selector struct {
+	label    string
+	children []Node
+	running  int // -1 when no child is running
+}
+
+func (n *selector) Label() string { return n.label }
+
+func (n *selector) Tick(t *Tree) Status {
+	for i, c := range n.children {
+		s := t.tick(c)
+		if s == Failure { continue }
+		if n.running >= 0 && n.running != i {
+			n.children[n.running].Reset()
+		}
+		n.running = -1
+		if s == Running { n.running = i }
+		return s
+	}
+	n.running = -1
+	return Failure
+}
+
+func (n *selector) Reset() {
+	n.running = -1
+	for _, c := range n.children {
+		c.Reset()
+	}
+}
+
+// parallel ticks every unfinished child each tick. With requireAll it succeeds once all children
+// have succeeded and fails on the first failure; otherwise the first success wins and it fails
+// only when every child has failed.
+type parallel struct {
+	label      string
+	children   []Node
+	requireAll bool
+	done       []Status // Running for children still going
+}
+
+func (n *parallel) Label() string { return n.label }
+
+func (n *parallel) Tick(t *Tree) Status {
+	successes, failures := 0, 0
+	for i, c := range n.children {
+		if n.done[i] == Running {
+			n.done[i] = t.tick(c)
+		}
+		switch n.done[i] {
+		case Success:
+			successes++
+		case Failure:
+			failures++
+		}
+	}
+	result := Running
+	switch {
+	case n.requireAll && failures > 0, !n.requireAll && failures == len(n.children):
+		result = Failure
+	case n.requireAll && successes == len(n.children), !n.requireAll && successes > 0:
+		result = Success
+	}
+	if result != Running { n.Reset() }
+	return result
+}
+
+func (n *parallel) Reset() {
+	for i, c := range n.children {
+		n.done[i] = Running
+		c.Reset()
+	}
+}
+
+// decorator wraps one child and rewrites its result
+type decorator struct {
+	label string
+	child Node
+	apply func(n *decorator, s Status) Status
+	count int // Successes so far, for repeat
+	limit int
+}
+
+func (n *decorator) Label() string { return n.label }
+
+func (n *decorator) Tick(t *Tree) Status {
+	return n.apply(n, t.tick(n.child))
+}
+
+func (n *decorator) Reset() {
+	n.count = 0
+	n.child.Reset()
+}
+
+func invert(_ *decorator, s Status) Status {
+	switch s {
+	case Success:
+		return Failure
+	case Failure:
+		return Success
+	}
+	return s
+}
+
+func succeed(_ *decorator, s Status) Status {
+	if s == Running { return Running }
+	return Success
+}
+
+// untilSuccess keeps the child going through failures, turning a condition into "wait for this"
+func untilSuccess(n *decorator, s Status) Status {
+	if s == Success { return Success }
+	if s == Failure { n.child.Reset() }
+	return Running
+}
+
+// repeat reruns a succeeding child limit times, or forever when limit is zero; a failure ends it
+func repeat(n *decorator, s Status) Status {
+	if s != Success { return s }
+	n.count++
+	n.child.Reset()
+	if n.limit > 0 && n.count >= n.limit {
+		n.count = 0
+		return Success
+	}
+	return Running
+}
+
+// cooldown fails while its child rests after succeeding, which lets a selector fall through to
+// something else instead of repeating the same move every tick. A failed attempt costs nothing.
+type cooldown struct {
+	label   string
+	child   Node
+	ticks   uint64
+	readyAt uint64
+}
+
+func (n *cooldown) Label() string { return n.label }
+
+func (n *cooldown) Tick(t *Tree) Status {
+	if t.Now < n.readyAt { return Failure }
+	s := t.tick(n.child)
+	if s == Success { n.readyAt = t.Now + n.ticks }
+	return s
+}
+
+// Reset keeps the cooldown itself; being pre-empted does not refund it
+func (n *cooldown) Reset() { n.child.Reset() }
+
+// wait runs for a fixed number of ticks and then succeeds
+type wait struct {
+	label   string
+	ticks   uint64
+	started bool
+	endAt   uint64
+}
+
+func (n *wait) Label() string { return n.label }
+
+func (n *wait) Tick(t *Tree) Status {
+	if !n.started {
+		n.started, n.endAt = true, t.Now+n.ticks
+	}
+	if t.Now < n.endAt { return Running }
+	n.started = false
+	return Success
+}
+
+func (n *wait) Reset() { n.started = false }
+
+// check tests a blackboard value without calling into the host: truthiness by default, or a
+// numeric range when above or below is set
+type check struct {
+	label        string
+	key          string
+	above, below *float64
+}
+
+func (n *check) Label() string { return n.label }
+
+func (n *check) Tick(t *Tree) Status {
+	v := t.Blackboard.Get(n.key)
+	if n.above == nil && n.below == nil {
+		if v == nil || v == false { return Failure }
+		return Success
+	}
+	f, ok := v.(float64)
+	if !ok { return Failure }
+	if n.above != nil && f <= *n.above { return Failure }
+	if n.below != nil && f >= *n.below { return Failure }
+	return Success
+}
+
+func (n *check) Reset() {}
+
+// leaf calls into the host. Conditions cannot run across ticks, so a running condition counts as failed.
+type leaf struct {
+	label     string
+	fn        Leaf
+	condition bool
+}
+
+func (n *leaf) Label() string { return n.label }
+
+func (n *leaf) Tick(t *Tree) Status {
+	s := n.fn()
+	if n.condition && s == Running { return Failure }
+	return s
+}
+
+func (n *leaf) Reset() {}
@@ -0,0 +1,143 @@
+package bt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// script returns a leaf that plays back statuses, repeating the last one, and counts its calls
+func script(calls *int, statuses ...Status) Leaf {
+	return func() Status {
+		i := min(*calls, len(statuses)-1)
+		*calls++
+		return statuses[i]
+	}
+}
+
+func f(v float64) *float64 { return &v }
+
+func TestTreeStatuses(t *testing.T) {
+	leaf := func(fn string) Spec { return Spec{Type: "action", Fn: fn} }
+	tests := []struct {
+		name  string
+		spec  Spec
+		ticks int
+		bb    MapBlackboard
+		want  []Status
+	}{
+		{"Sequence stops at failure", Spec{Type: "sequence", Children: []Spec{leaf("ok"), leaf("no"), leaf("ok")}}, 1, nil, []Status{Failure}},
+		{"Sequence resumes running child", Spec{Type: "sequence", Children: []Spec{leaf("ok"), leaf("slow")}}, 3, nil, []Status{Running, Running, Success}},
+		{"Selector falls through", Spec{Type: "selector", Children: []Spec{leaf("no"), leaf("ok")}}, 1, nil, []Status{Success}},
+		{"Selector fails when all fail", Spec{Type: "selector", Children: []Spec{leaf("no"), leaf("no")}}, 1, nil, []Status{Failure}},
+		{"Parallel one", Spec{Type: "parallel", Children: []Spec{leaf("slow"), Spec{Type: "wait", Seconds: 1}}}, 3, nil, []Status{Running, Running, Success}},
+		{"Parallel all", Spec{Type: "parallel", Policy: "all", Children: []Spec{leaf("slow"), leaf("ok")}}, 3, nil, []Status{Running, Running, Success}},
+		{"Parallel all fails", Spec{Type: "parallel", Policy: "all", Children: []Spec{leaf("slow"), leaf("no")}}, 1, nil, []Status{Failure}},
+		{"Invert", Spec{Type: "invert", Children: []Spec{leaf("no")}}, 1, nil, []Status{Success}},
+		{"Succeed", Spec{Type: "succeed", Children: []Spec{leaf("no")}}, 1, nil, []Status{Success}},
+		{"Repeat", Spec{Type: "repeat", Count: 2, Children: []Spec{leaf("ok")}}, 3, nil, []Status{Running, Success, Running}},
+		{"Until success", Spec{Type: "until_success", Children: []Spec{leaf("late")}}, 3, nil, []Status{Running, Running, Success}},
+		{"Wait", Spec{Type: "wait", Seconds: 2.0 / 60}, 3, nil, []Status{Running, Running, Success}},
+		{"Cooldown", Spec{Type: "cooldown", Seconds: 2.0 / 60, Children: []Spec{leaf("ok")}}, 4, nil, []Status{Success, Failure, Success, Failure}},
+		{"Cooldown ignores failures", Spec{Type: "cooldown", Seconds: 1, Children: []Spec{leaf("late")}}, 3, nil, []Status{Failure, Failure, Success}},
+		{"Check truthy", Spec{Type: "check", Key: "seen"}, 1, MapBlackboard{"seen": true}, []Status{Success}},
+		{"Check missing", Spec{Type: "check", Key: "seen"}, 1, MapBlackboard{}, []Status{Failure}},
+		{"Check range", Spec{Type: "check", Key: "stamina", Above: f(30), Below: f(50)}, 1, MapBlackboard{"stamina": 40.0}, []Status{Success}},
+		{"Check below range", Spec{Type: "check", Key: "stamina", Above: f(30)}, 1, MapBlackboard{"stamina": 30.0}, []Status{Failure}},
+		{"Condition cannot run", Spec{Type: "condition", Fn: "slow"}, 1, nil, []Status{Failure}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var okN, noN, slowN, lateN int
+			leaves := map[string]Leaf{
+				"ok":   script(&okN, Success),
+				"no":   script(&noN, Failure),
+				"slow": script(&slowN, Running, Running, Success),
+				"late": script(&lateN, Failure, Failure, Success),
+			}
+			tree, err := Build("test", tt.spec, func(name string) (Leaf, bool) { l, ok := leaves[name]; return l, ok })
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.ticks; i++ {
+				if got := tree.Tick(uint64(i), tt.bb); got != tt.want[i] {
+					t.Errorf("tick %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectorPreemptsRunningChild(t *testing.T) {
+	bb := MapBlackboard{}
+	var calls int
+	spec := Spec{Type: "selector", Children: []Spec{
+		{Type: "sequence", Name: "flee", Children: []Spec{{Type: "check", Key: "danger"}, {Type: "action", Fn: "run"}}},
+		{Type: "sequence", Name: "idle", Children: []Spec{{Type: "action", Fn: "run"}, {Type: "wait", Seconds: 1}}},
+	}}
+	tree, err := Build("test", spec, func(string) (Leaf, bool) { return script(&calls, Success), true })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Tick(0, bb)
+	if got := tree.ActivePath(); got != "selector > idle > wait 1s" {
+		t.Errorf("ActivePath() = %q, want the idle wait", got)
+	}
+	bb["danger"] = true
+	tree.Tick(1, bb)
+	if got := tree.ActivePath(); got != "selector > flee > run" {
+		t.Errorf("ActivePath() = %q, want flee to pre-empt", got)
+	}
+	// The abandoned wait must start over rather than finish early
+	bb["danger"] = false
+	for i := uint64(2); i < 40; i++ {
+		if tree.Tick(i, bb) != Running {
+			t.Fatalf("tick %d finished; the pre-empted wait was not reset", i)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     Spec
+		wantPath string
+		wantMsg  string
+	}{
+		{"Unknown type", Spec{Type: "sequnce"}, "", "unknown node type"},
+		{"Missing type", Spec{}, "", "needs a type"},
+		{"Empty composite", Spec{Type: "selector"}, "", "needs children"},
+		{"Decorator arity", Spec{Type: "invert", Children: []Spec{{Type: "wait"}, {Type: "wait"}}}, "", "exactly one child"},
+		{"Unknown leaf", Spec{Type: "sequence", Children: []Spec{{Type: "wait"}, {Type: "action", Fn: "fly"}}}, "children[1]", `unknown leaf "fly"`},
+		{"Bad policy", Spec{Type: "parallel", Policy: "most", Children: []Spec{{Type: "wait"}}}, "", "policy"},
+		{"Cooldown without time", Spec{Type: "cooldown", Children: []Spec{{Type: "wait"}}}, "", "positive seconds"},
+		{"Check without key", Spec{Type: "selector", Children: []Spec{{Type: "invert", Children: []Spec{{Type: "check"}}}}}, "children[0].children[0]", "needs a key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build("test", tt.spec, func(string) (Leaf, bool) { return nil, false })
+			var se *SpecError
+			if !errors.As(err, &se) {
+				t.Fatalf("Build() error = %v, want a SpecError", err)
+			}
+			if se.Path != tt.wantPath || !strings.Contains(se.Msg, tt.wantMsg) {
+				t.Errorf("Build() error = %q at %q, want %q at %q", se.Msg, se.Path, tt.wantMsg, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestLoadFileRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.json")
+	if err := os.WriteFile(path, []byte(`{"type": "wait", "secnds": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "secnds") {
+		t.Errorf("LoadFile() error = %v, want the unknown key named", err)
+	}
+}
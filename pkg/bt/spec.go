@@ -0,0 +1,161 @@
+package bt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"beautifulmess/pkg/core"
+)
+
+// Spec describes one node and, through Children, everything under it. It is the on-disk format:
+// a tree file holds the root Spec as JSON.
+type Spec struct {
+	Type     string   `json:"type"`
+	Name     string   `json:"name,omitempty"` // Shown in the debug view; defaults to a summary of the node
+	Children []Spec   `json:"children,omitempty"`
+	Seconds  float64  `json:"seconds,omitempty"` // wait and cooldown
+	Count    int      `json:"count,omitempty"`   // repeat; zero repeats forever
+	Policy   string   `json:"policy,omitempty"`  // parallel: "one" (default) or "all"
+	Fn       string   `json:"fn,omitempty"`      // action and condition: the host leaf to call
+	Key      string   `json:"key,omitempty"`     // check: blackboard entry to test
+	Above    *float64 `json:"above,omitempty"`
+	Below    *float64 `json:"below,omitempty"`
+}
+
+// SpecError points at the offending node, e.g. "children[1].children[0]: unknown leaf \"jnk\""
+type SpecError struct {
+	Path string
+	Msg  string
+}
+
+func (e *SpecError) Error() string {
+	if e.Path == "" { return e.Msg }
+	return e.Path + ": " + e.Msg
+}
+
+// LeafResolver finds the host function for an action or condition
+type LeafResolver func(name string) (Leaf, bool)
+
+// Build instantiates a fresh tree from spec; each call returns independent node state
+func Build(name string, spec Spec, leaves LeafResolver) (*Tree, error) {
+	root, err := build(spec, "", leaves)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{Name: name, Root: root}, nil
+}
+
+func build(s Spec, path string, leaves LeafResolver) (Node, error) {
+	fail := func(format string, args ...any) (Node, error) {
+		return nil, &SpecError{Path: path, Msg: fmt.Sprintf(format, args...)}
+	}
+	label := s.Name
+	if label == "" { label = s.defaultLabel() }
+
+	children := make([]Node, len(s.Children))
+	for i, c := range s.Children {
+		childPath := fmt.Sprintf("children[%d]", i)
+		if path != "" { childPath = path + "." + childPath }
+		n, err := build(c, childPath, leaves)
+		if err != nil { return nil, err }
+		children[i] = n
+	}
+	needChildren := func() bool { return len(children) > 0 }
+	needOne := func() bool { return len(children) == 1 }
+
+	switch s.Type {
+	case "sequence":
+		if !needChildren() { return fail("sequence needs children") }
+		return &sequence{label: label, children: children}, nil
+	case "selector":
+		if !needChildren() { return fail("selector needs children") }
+		return &selector{label: label, children: children, running: -1}, nil
+	case "parallel":
+		if !needChildren() { return fail("parallel needs children") }
+		if s.Policy != "" && s.Policy != "one" && s.Policy != "all" {
+			return fail("parallel policy must be \"one\" or \"all\", got %q", s.Policy)
+		}
+		p := &parallel{label: label, children: children, requireAll: s.Policy == "all", done: make([]Status, len(children))}
+		p.Reset()
+		return p, nil
+	case "invert", "succeed", "until_success", "repeat":
+		if !needOne() { return fail("%s takes exactly one child", s.Type) }
+		if s.Count < 0 { return fail("count must not be negative") }
+		apply := map[string]func(*decorator, Status) Status{
+			"invert": invert, "succeed": succeed, "until_success": untilSuccess, "repeat": repeat,
+		}[s.Type]
+		return &decorator{label: label, child: children[0], apply: apply, limit: s.Count}, nil
+	case "cooldown":
+		if !needOne() { return fail("cooldown takes exactly one child") }
+		if s.Seconds <= 0 { return fail("cooldown needs a positive seconds") }
+		return &cooldown{label: label, child: children[0], ticks: ticksFor(s.Seconds)}, nil
+	case "wait":
+		if needChildren() { return fail("wait takes no children") }
+		if s.Seconds < 0 { return fail("seconds must not be negative") }
+		return &wait{label: label, ticks: ticksFor(s.Seconds)}, nil
+	case "check":
+		if s.Key == "" { return fail("check needs a key") }
+		return &check{label: label, key: s.Key, above: s.Above, below: s.Below}, nil
+	case "action", "condition":
+		if s.Fn == "" { return fail("%s needs fn", s.Type) }
+		fn, ok := leaves(s.Fn)
+		if !ok { return fail("unknown leaf %q", s.Fn) }
+		return &leaf{label: label, fn: fn, condition: s.Type == "condition"}, nil
+	case "":
+		return fail("node needs a type")
+	}
+	return fail("unknown node type %q", s.Type)
+}
+
+func (s Spec) defaultLabel() string {
+	switch s.Type {
+	case "wait", "cooldown":
+		return fmt.Sprintf("%s %gs", s.Type, s.Seconds)
+	case "action", "condition":
+		return s.Fn
+	case "check":
+		var b strings.Builder
+		b.WriteString("check " + s.Key)
+		if s.Above != nil { fmt.Fprintf(&b, " > %g", *s.Above) }
+		if s.Below != nil { fmt.Fprintf(&b, " < %g", *s.Below) }
+		return b.String()
+	}
+	return s.Type
+}
+
+// ticksFor matches world.TicksFor; the world package depends on this one, so it cannot be imported
+func ticksFor(seconds float64) uint64 {
+	if seconds <= 0 { return 0 }
+	return uint64(seconds/core.TimeStep + 0.5)
+}
+
+// LoadFile reads a tree spec written as JSON. Unknown keys are rejected so a typo cannot silently
+// fall back to a default.
+func LoadFile(path string) (Spec, error) {
+	var s Spec
+	data, err := os.ReadFile(path)
+	if err != nil { return s, err }
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// LoadDir reads every *.json tree in dir, keyed by file name without the extension
+func LoadDir(dir string) (map[string]Spec, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil { return nil, err }
+	specs := make(map[string]Spec, len(paths))
+	for _, p := range paths {
+		s, err := LoadFile(p)
+		if err != nil { return nil, err }
+		specs[strings.TrimSuffix(filepath.Base(p), ".json")] = s
+	}
+	return specs, nil
+}
@@ -1,11 +1,13 @@
 package components
 
 import (
+	"image"
 	"image/color"
 
+	"beautifulmess/pkg/bt"
 	"beautifulmess/pkg/core"
 
-	"github.com/hajimehoshi/ebiten/v2"
+	lua "github.com/yuin/gopher-lua"
 )
 
 type Transform struct {
@@ -19,16 +21,74 @@ type Physics struct {
 	GravityMultiplier        float64 // Allows entities to react differently to the curvature of space
 }
 
+// Sprite is whatever the render backend draws; the simulation only ever needs its size
+type Sprite interface {
+	Bounds() image.Rectangle
+}
+
 type Render struct {
-	Sprite *ebiten.Image
+	Sprite Sprite
 	Color  color.RGBA
 	Glow   bool
 	Scale  float64 // Non-zero scale values enable resolution-independent sprite sizing
+	Layer  RenderLayer
 }
 
+// RenderLayer orders drawing: higher layers are drawn over lower ones, and within a layer the
+// lower entity is drawn first
+type RenderLayer int
+
+const (
+	LayerWalls   RenderLayer = iota // The level itself, under everything that moves
+	LayerDecoys                     // Lures lie on the ground for the ships to pass over
+	LayerShips                      // The spectre and the runner
+	LayerBullets                    // Shots stay visible as they pass over a ship
+)
+
 type AI struct {
 	ScriptName string
-	TargetID   int
+	TargetID   core.Handle // Goes stale rather than pointing elsewhere once the target is destroyed
+	State      *lua.LTable // Per-entity script memory passed as self; created on first update, dropped with the entity
+	Behaviour  *Coroutine  // The script's behave function, suspended between ticks
+	Inbox      []*lua.LTable // Events waiting for the script's on_event at its next update
+
+	Personality string   // Variant the script should play, e.g. which behaviour tree a spectre runs this chapter
+	Tree        *bt.Tree // Behaviour tree the script last ticked for this entity, for the debug view
+
+	Driver   AIDriver
+	Failures int // Consecutive ticks the script failed; enough of them hand the entity to the native fallback
+	Native   any // Memory of the native implementation, owned by whichever one is driving
+}
+
+// AIDriver picks between an entity's script and the native Go implementation of it
+type AIDriver int
+
+const (
+	DriverAuto   AIDriver = iota // The script, falling back to native code when it is missing or keeps failing
+	DriverScript                 // The script only, even while it is broken
+	DriverNative                 // Native code only; the script is never called
+)
+
+var driverNames = [...]string{"auto", "script", "native"}
+
+func (d AIDriver) String() string { return driverNames[d] }
+
+// ParseAIDriver reads a driver name as written on the command line
+func ParseAIDriver(name string) (AIDriver, bool) {
+	for i, n := range driverNames {
+		if n == name { return AIDriver(i), true }
+	}
+	return DriverAuto, false
+}
+
+// Coroutine is a long-running script behaviour and the condition it is waiting on
+type Coroutine struct {
+	Thread   *lua.LState
+	Fn       *lua.LFunction // The behave function it was started from; a reload that replaces it restarts the coroutine
+	WakeTick uint64         // Sim tick the coroutine sleeps until
+	Until    *lua.LFunction // Resume once this returns true; nil when only sleeping
+	Deadline uint64         // Resume anyway at this tick when waiting with a timeout; zero means no timeout
+	Done     bool
 }
 
 type Tag struct {
@@ -49,8 +109,8 @@ type Wall struct {
 }
 
 type ProjectileEmitter struct {
-	Interval float64 // Fixed-step intervals ensure deterministic fire rates across different hardware
-	LastTime float64
+	Interval float64 // Seconds between shots; converted to sim ticks so fire rate ignores frame pacing
+	LastTime uint64  // Sim tick of the last shot; zero means the emitter has not fired yet
 }
 
 type Lifetime struct {
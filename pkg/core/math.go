@@ -34,4 +34,11 @@ func WrapPosition(p *Vector2) {
 	if p.Y >= ScreenHeight { p.Y -= ScreenHeight }
 }
 
+// WrapFully is WrapPosition for points any number of screens away, such as the end of a long ray
+// or a position handed in by a script
+func WrapFully(p *Vector2) {
+	p.X = math.Mod(math.Mod(p.X, ScreenWidth)+ScreenWidth, ScreenWidth)
+	p.Y = math.Mod(math.Mod(p.Y, ScreenHeight)+ScreenHeight, ScreenHeight)
+}
+
 
@@ -13,8 +13,31 @@ const (
 
 // Vector2
 type Vector2 struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 // Entity ID
 type Entity int
+
+// Handle names one entity for as long as it lives: its slot plus the generation the slot was on.
+// Slots are reused, so anything that keeps an entity across ticks should keep a Handle and check
+// it with World.Alive. The zero Handle names nothing.
+type Handle struct {
+	ID  Entity
+	Gen uint32
+}
+
+// HandleIndexBits is how many low bits of a packed handle hold the slot, capping a world's slots
+// at 1<<HandleIndexBits; the generation above them keeps the whole number exact in a float64
+const HandleIndexBits = 20
+
+// Pack folds h into one integer, which is how scripts see entities
+func (h Handle) Pack() int64 {
+	return int64(h.Gen)<<HandleIndexBits | int64(h.ID)
+}
+
+// UnpackHandle reverses Pack
+func UnpackHandle(v int64) Handle {
+	return Handle{ID: Entity(v & (1<<HandleIndexBits - 1)), Gen: uint32(v >> HandleIndexBits)}
+}
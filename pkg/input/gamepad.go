@@ -0,0 +1,93 @@
+package input
+
+import (
+	"log"
+	"math"
+
+	"beautifulmess/pkg/core"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// StickDeadzone swallows the resting drift of worn analog sticks
+	StickDeadzone = 0.2
+	// TriggerThreshold is how far an analog trigger must travel before it counts as held
+	TriggerThreshold = 0.3
+)
+
+// PadBindings maps actions onto the standard gamepad layout, which ebiten normalises across controller brands
+type PadBindings map[Action][]ebiten.StandardGamepadButton
+
+// DefaultPadBindings puts menus on the D-pad and face buttons and boost on the right trigger
+func DefaultPadBindings() PadBindings {
+	return PadBindings{
+		ActionMoveUp:    {ebiten.StandardGamepadButtonLeftTop},
+		ActionMoveDown:  {ebiten.StandardGamepadButtonLeftBottom},
+		ActionMoveLeft:  {ebiten.StandardGamepadButtonLeftLeft},
+		ActionMoveRight: {ebiten.StandardGamepadButtonLeftRight},
+		ActionBoost:     {ebiten.StandardGamepadButtonFrontBottomRight, ebiten.StandardGamepadButtonFrontBottomLeft},
+		ActionConfirm:   {ebiten.StandardGamepadButtonRightBottom},
+		ActionBack:      {ebiten.StandardGamepadButtonRightRight},
+		ActionPause:     {ebiten.StandardGamepadButtonCenterRight},
+		ActionMenu:      {ebiten.StandardGamepadButtonCenterLeft},
+		ActionPrevPhoto: {ebiten.StandardGamepadButtonLeftLeft, ebiten.StandardGamepadButtonFrontTopLeft},
+		ActionNextPhoto: {ebiten.StandardGamepadButtonLeftRight, ebiten.StandardGamepadButtonFrontTopRight},
+	}
+}
+
+// Update refreshes the set of connected controllers. Calling it every frame is what makes hot-plugging work.
+func (m *Mapper) Update() {
+	for _, id := range inpututil.AppendJustConnectedGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			log.Printf("gamepad %d connected: %s", id, ebiten.GamepadName(id))
+		} else {
+			log.Printf("gamepad %d connected without a standard layout, ignoring: %s", id, ebiten.GamepadName(id))
+		}
+	}
+	for _, id := range m.pads {
+		if inpututil.IsGamepadJustDisconnected(id) {
+			log.Printf("gamepad %d disconnected", id)
+		}
+	}
+
+	m.pads = m.pads[:0]
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			m.pads = append(m.pads, id)
+		}
+	}
+}
+
+func padPressed(id ebiten.GamepadID, b ebiten.StandardGamepadButton) bool {
+	// Triggers are analog; a threshold keeps a resting finger from holding boost
+	if b == ebiten.StandardGamepadButtonFrontBottomLeft || b == ebiten.StandardGamepadButtonFrontBottomRight {
+		return ebiten.StandardGamepadButtonValue(id, b) > TriggerThreshold
+	}
+	return ebiten.IsStandardGamepadButtonPressed(id, b)
+}
+
+// stickDir sums the left sticks of every connected pad so any controller can steer
+func (m *Mapper) stickDir() core.Vector2 {
+	var dir core.Vector2
+	for _, id := range m.pads {
+		x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		d := ApplyDeadzone(x, y, StickDeadzone)
+		dir.X += d.X
+		dir.Y += d.Y
+	}
+	return dir
+}
+
+// ApplyDeadzone zeroes small deflections and rescales the rest so output still ramps smoothly from 0 to 1.
+// The deadzone is radial, so diagonals are not penalised the way per-axis deadzones would.
+func ApplyDeadzone(x, y, deadzone float64) core.Vector2 {
+	mag := math.Hypot(x, y)
+	if mag <= deadzone {
+		return core.Vector2{}
+	}
+	scale := math.Min(1, (mag-deadzone)/(1-deadzone)) / mag
+	return core.Vector2{X: x * scale, Y: y * scale}
+}
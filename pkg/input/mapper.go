@@ -0,0 +1,188 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"beautifulmess/pkg/core"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Action is something the player means to do, independent of which key does it
+type Action int
+
+const (
+	ActionMoveUp Action = iota
+	ActionMoveDown
+	ActionMoveLeft
+	ActionMoveRight
+	ActionBoost
+	ActionConfirm
+	ActionBack
+	ActionPause
+	ActionMenu // Leave the pause menu for the title screen
+	ActionPrevPhoto
+	ActionNextPhoto
+	ActionFullscreen
+	ActionDevOverlay
+	ActionDevSelect // Cycle which entity the developer overlay inspects
+	NumActions
+)
+
+var actionNames = [NumActions]string{
+	"move_up", "move_down", "move_left", "move_right", "boost",
+	"confirm", "back", "pause", "menu", "prev_photo", "next_photo", "fullscreen",
+	"dev_overlay", "dev_select",
+}
+
+// Labels are what the controls menu shows; file keys stay stable even if these change
+var actionLabels = [NumActions]string{
+	"MOVE UP", "MOVE DOWN", "MOVE LEFT", "MOVE RIGHT", "BOOST",
+	"CONFIRM", "BACK", "PAUSE", "QUIT TO MENU", "PREV PHOTO", "NEXT PHOTO", "FULLSCREEN",
+	"DEV OVERLAY", "DEV SELECT",
+}
+
+func (a Action) String() string { return actionNames[a] }
+func (a Action) Label() string  { return actionLabels[a] }
+
+func (a Action) MarshalText() ([]byte, error) {
+	if a < 0 || a >= NumActions {
+		return nil, fmt.Errorf("unknown action %d", int(a))
+	}
+	return []byte(a.String()), nil
+}
+
+func (a *Action) UnmarshalText(text []byte) error {
+	for i, name := range actionNames {
+		if name == string(text) {
+			*a = Action(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// Bindings lists the keys that trigger each action; any one of them is enough
+type Bindings map[Action][]ebiten.Key
+
+// DefaultBindings keeps both arrow keys and WASD so either grip works out of the box
+func DefaultBindings() Bindings {
+	return Bindings{
+		ActionMoveUp:     {ebiten.KeyArrowUp, ebiten.KeyW},
+		ActionMoveDown:   {ebiten.KeyArrowDown, ebiten.KeyS},
+		ActionMoveLeft:   {ebiten.KeyArrowLeft, ebiten.KeyA},
+		ActionMoveRight:  {ebiten.KeyArrowRight, ebiten.KeyD},
+		ActionBoost:      {ebiten.KeyShift, ebiten.KeyC},
+		ActionConfirm:    {ebiten.KeySpace, ebiten.KeyEnter},
+		ActionBack:       {ebiten.KeyEscape},
+		ActionPause:      {ebiten.KeyP},
+		ActionMenu:       {ebiten.KeyM},
+		ActionPrevPhoto:  {ebiten.KeyArrowLeft, ebiten.KeyA},
+		ActionNextPhoto:  {ebiten.KeyArrowRight, ebiten.KeyD},
+		ActionFullscreen: {ebiten.KeyF11},
+		ActionDevOverlay: {ebiten.KeyF3},
+		ActionDevSelect:  {ebiten.KeyF4},
+	}
+}
+
+// Mapper resolves actions against the current bindings. It is the only place the game reads keys or pads.
+type Mapper struct {
+	Bindings    Bindings
+	PadBindings PadBindings
+	pads        []ebiten.GamepadID // Connected standard-layout pads, refreshed by Update
+}
+
+func NewMapper() *Mapper {
+	return &Mapper{Bindings: DefaultBindings(), PadBindings: DefaultPadBindings()}
+}
+
+func (m *Mapper) Pressed(a Action) bool {
+	for _, k := range m.Bindings[a] {
+		if ebiten.IsKeyPressed(k) { return true }
+	}
+	for _, id := range m.pads {
+		for _, b := range m.PadBindings[a] {
+			if padPressed(id, b) { return true }
+		}
+	}
+	return false
+}
+
+func (m *Mapper) JustPressed(a Action) bool {
+	for _, k := range m.Bindings[a] {
+		if inpututil.IsKeyJustPressed(k) { return true }
+	}
+	for _, id := range m.pads {
+		for _, b := range m.PadBindings[a] {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, b) { return true }
+		}
+	}
+	return false
+}
+
+// MoveDir implements world.Input. Digital input wins outright; otherwise the analog stick
+// steers proportionally, so a half-tilt accelerates at half strength.
+func (m *Mapper) MoveDir() core.Vector2 {
+	dir := core.Vector2{}
+	if m.Pressed(ActionMoveLeft) { dir.X -= 1 }
+	if m.Pressed(ActionMoveRight) { dir.X += 1 }
+	if m.Pressed(ActionMoveUp) { dir.Y -= 1 }
+	if m.Pressed(ActionMoveDown) { dir.Y += 1 }
+	if dir != (core.Vector2{}) { return dir }
+
+	stick := m.stickDir()
+	// Two pads pushing together must not exceed a single full deflection
+	if l := math.Hypot(stick.X, stick.Y); l > 1 {
+		stick.X, stick.Y = stick.X/l, stick.Y/l
+	}
+	return stick
+}
+
+// Boost implements world.Input
+func (m *Mapper) Boost() bool {
+	return m.Pressed(ActionBoost)
+}
+
+// Rebind makes k the primary key for a, keeping the old primary as the alternate
+func (m *Mapper) Rebind(a Action, k ebiten.Key) {
+	keys := []ebiten.Key{k}
+	for _, old := range m.Bindings[a] {
+		if old != k && len(keys) < 2 { keys = append(keys, old) }
+	}
+	m.Bindings[a] = keys
+}
+
+// KeyLabel names the keys bound to a for on-screen prompts, e.g. "SPACE/ENTER"
+func (m *Mapper) KeyLabel(a Action) string {
+	names := make([]string, 0, len(m.Bindings[a]))
+	for _, k := range m.Bindings[a] {
+		names = append(names, strings.ToUpper(strings.TrimPrefix(k.String(), "Arrow")))
+	}
+	if len(names) == 0 { return "UNBOUND" }
+	return strings.Join(names, "/")
+}
+
+// Load merges bindings saved by Save over the defaults; actions missing from the file keep their default keys
+func (m *Mapper) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil { return err }
+	var saved Bindings
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for a, keys := range saved {
+		m.Bindings[a] = keys
+	}
+	return nil
+}
+
+func (m *Mapper) Save(path string) error {
+	data, err := json.MarshalIndent(m.Bindings, "", "  ")
+	if err != nil { return err }
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,115 @@
+package input
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestBindingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	m := NewMapper()
+	m.Rebind(ActionBoost, ebiten.KeySpace)
+	if err := m.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewMapper()
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Bindings, m.Bindings) {
+		t.Errorf("loaded bindings = %v, want %v", loaded.Bindings, m.Bindings)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name string
+		key  ebiten.Key
+		want []ebiten.Key
+	}{
+		{"New key becomes primary", ebiten.KeyX, []ebiten.Key{ebiten.KeyX, ebiten.KeyShift}},
+		{"Existing alternate is promoted", ebiten.KeyC, []ebiten.Key{ebiten.KeyC, ebiten.KeyShift}},
+		{"Same key is a no-op", ebiten.KeyShift, []ebiten.Key{ebiten.KeyShift, ebiten.KeyC}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMapper()
+			m.Rebind(ActionBoost, tt.key)
+			if got := m.Bindings[ActionBoost]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bindings[boost] = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"Unknown action", `{"jump": ["Space"]}`},
+		{"Unknown key", `{"boost": ["Hyper"]}`},
+		{"Malformed", `{"boost": "Shift"}`},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "controls.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewMapper().Load(path); err == nil {
+				t.Error("Load() accepted invalid bindings")
+			}
+		})
+	}
+}
+
+func TestLoadKeepsDefaultsForMissingActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "controls.json")
+	if err := os.WriteFile(path, []byte(`{"pause": ["Tab"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMapper()
+	if err := m.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Bindings[ActionPause]; !reflect.DeepEqual(got, []ebiten.Key{ebiten.KeyTab}) {
+		t.Errorf("Bindings[pause] = %v, want [Tab]", got)
+	}
+	if got := m.Bindings[ActionConfirm]; !reflect.DeepEqual(got, DefaultBindings()[ActionConfirm]) {
+		t.Errorf("Bindings[confirm] = %v, want defaults", got)
+	}
+}
+
+func TestApplyDeadzone(t *testing.T) {
+	tests := []struct {
+		name  string
+		x, y  float64
+		wantX float64
+		wantY float64
+	}{
+		{"Resting drift", 0.1, -0.1, 0, 0},
+		{"Edge of deadzone", 0.2, 0, 0, 0},
+		{"Half tilt", 0.6, 0, 0.5, 0},
+		{"Full tilt", 0, -1, 0, -1},
+		{"Overshooting corner", 1, 1, math.Sqrt2 / 2, math.Sqrt2 / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyDeadzone(tt.x, tt.y, 0.2)
+			if math.Abs(got.X-tt.wantX) > 1e-9 || math.Abs(got.Y-tt.wantY) > 1e-9 {
+				t.Errorf("ApplyDeadzone(%v, %v) = %+v, want {%v %v}", tt.x, tt.y, got, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
@@ -2,53 +2,64 @@ package level
 
 import (
 	"image/color"
-	"math"
-	"math/rand"
 	"beautifulmess/pkg/core"
 )
 
 type GravityWell struct {
-	Position core.Vector2
-	Radius   float64
-	Mass     float64
+	Position core.Vector2 `json:"position"`
+	Radius   float64      `json:"radius"`
+	Mass     float64      `json:"mass"`
 }
 
 type MemoryNode struct {
-	Position     core.Vector2
-	Title        string
-	Descriptions []string
-	Color        color.RGBA
-	Photos       []string
+	Position     core.Vector2 `json:"position"`
+	Title        string       `json:"title"`
+	Descriptions []string     `json:"descriptions"`
+	Color        color.RGBA   `json:"color"`
+	Photos       []string     `json:"photos"`
 }
 
 type WallDef struct {
-	X, Y         float64
-	Destructible bool
+	X            float64 `json:"x"`
+	Y            float64 `json:"y"`
+	Destructible bool    `json:"destructible,omitempty"`
 }
 
 type Level struct {
-	Name     string
-	Wells    []GravityWell
-	Walls    []WallDef
-	Memory   MemoryNode
-	StartP1  core.Vector2
-	StartP2  core.Vector2
-	Friction float64 // Friction override for specialized gameplay feel
+	Name     string        `json:"name"`
+	Wells    []GravityWell `json:"wells,omitempty"`
+	Walls    []WallDef     `json:"walls,omitempty"`
+	Memory   MemoryNode    `json:"memory"`
+	StartP1  core.Vector2  `json:"start_p1"`
+	StartP2  core.Vector2  `json:"start_p2"`
+	Friction float64       `json:"friction"` // Friction override for specialized gameplay feel
+	Seed     int64         `json:"seed,omitempty"` // Drives procedural placement; resolved to a concrete value at load
+	// Personality picks the spectre's behaviour tree (behaviours/spectre_<personality>.json); empty means the default
+	Personality string `json:"personality,omitempty"`
 }
 
-func InitLevels() []Level {
-	// Procedural generation helpers reduce boilerplate and ensure grid-alignment
-	genLine := func(x1, y1, x2, y2 int, dest bool) []WallDef {
-		var walls []WallDef
-		steps := int(math.Max(math.Abs(float64(x2-x1)), math.Abs(float64(y2-y1))) / 10)
-		for i := 0; i <= steps; i++ {
-			t := float64(i) / float64(steps)
-			walls = append(walls, WallDef{X: float64(x1) + t*float64(x2-x1), Y: float64(y1) + t*float64(y2-y1), Destructible: dest})
+// InitLevels returns the built-in chapter set. Shipped builds read the same chapters
+// from the levels/ directory via LoadLevels; this copy is the fallback when it is missing.
+// A non-zero seed pins every procedural layout, as with LoadLevels.
+func InitLevels(seed int64) []Level {
+	// Built-in chapters share the shape primitives used by level files, so both paths stay in lockstep
+	walls := func(shapes ...ShapeDef) []WallDef {
+		var out []WallDef
+		for _, s := range shapes {
+			w, err := s.Expand()
+			if err != nil {
+				panic(err)
+			}
+			out = append(out, w...)
 		}
-		return walls
+		return out
 	}
+	line := func(x1, y1, x2, y2 float64, dest bool) ShapeDef {
+		return ShapeDef{Type: "line", Points: []core.Vector2{{X: x1, Y: y1}, {X: x2, Y: y2}}, Destructible: dest}
+	}
+	kaleidoscopeSeed := ResolveSeed(seed, 0)
 
-	return []Level{
+	levels := []Level{
 		// 1. Where It All Began: The Spark (Normal Mechanics)
 		{
 			Name: "The Spark",
@@ -64,24 +75,20 @@ func InitLevels() []Level {
 				Color:  color.RGBA{255, 100, 150, 255},
 				Photos: []string{"assets/FIRSTMEET.jpg", "assets/LoveBug1.jpg", "assets/LoveBug2.jpg", "assets/firstintro1.jpg"},
 			},
-			StartP1:  core.Vector2{X: 100, Y: 360},
-			StartP2:  core.Vector2{X: 1100, Y: 360},
-			Friction: 0.94,
+			StartP1:     core.Vector2{X: 100, Y: 360},
+			StartP2:     core.Vector2{X: 1100, Y: 360},
+			Friction:    0.94,
+			Personality: "wary",
 		},
 		// 2. The Color of Your Soul: Kaleidoscope Twist (Many tiny wells)
 		{
 			Name: "Color of Your Soul",
-			Wells: func() []GravityWell {
-				var wells []GravityWell
-				for i := 0; i < 15; i++ {
-					wells = append(wells, GravityWell{
-						Position: core.Vector2{X: 200 + rand.Float64()*880, Y: 100 + rand.Float64()*520},
-						Radius:   25, Mass: 0.8,
-					})
-				}
-				return wells
-			}(),
-			Walls: append(genLine(300, 100, 980, 100, false), genLine(300, 620, 980, 620, false)...),
+			Seed: kaleidoscopeSeed,
+			Wells: ScatterDef{
+				Count: 15, Min: core.Vector2{X: 200, Y: 100}, Max: core.Vector2{X: 1080, Y: 620},
+				Radius: 25, Mass: 0.8,
+			}.Scatter(NewRNG(kaleidoscopeSeed)),
+			Walls: walls(line(300, 100, 980, 100, false), line(300, 620, 980, 620, false)),
 			Memory: MemoryNode{
 				Position: core.Vector2{X: 640, Y: 360},
 				Title:    "Discovery",
@@ -91,9 +98,10 @@ func InitLevels() []Level {
 				Color:  color.RGBA{150, 255, 150, 255},
 				Photos: []string{"assets/floweigirl.jpg", "assets/floweigirlteainspo.jpg", "assets/mint.jpg"},
 			},
-			StartP1:  core.Vector2{X: 100, Y: 100},
-			StartP2:  core.Vector2{X: 1180, Y: 620},
-			Friction: 0.92,
+			StartP1:     core.Vector2{X: 100, Y: 100},
+			StartP2:     core.Vector2{X: 1180, Y: 620},
+			Friction:    0.92,
+			Personality: "playful",
 		},
 		// 3. The Muffin Chapter: Catnip Twist (Wells in the face)
 		{
@@ -103,29 +111,27 @@ func InitLevels() []Level {
 				{Position: core.Vector2{X: 760, Y: 350}, Radius: 40, Mass: 1.5}, // Right Eye Well
 				{Position: core.Vector2{X: 640, Y: 420}, Radius: 30, Mass: 1.0}, // Nose Well
 			},
-			Walls: func() []WallDef {
-				var walls []WallDef
-				// Left Ear
-				walls = append(walls, genLine(450, 250, 500, 150, false)...)
-				walls = append(walls, genLine(500, 150, 550, 250, false)...)
-				// Right Ear
-				walls = append(walls, genLine(730, 250, 780, 150, false)...)
-				walls = append(walls, genLine(780, 150, 830, 250, false)...)
-				// Head Outline (Top)
-				walls = append(walls, genLine(550, 250, 730, 250, false)...)
+			Walls: walls(
+				// Left ear
+				line(450, 250, 500, 150, false),
+				line(500, 150, 550, 250, false),
+				// Right ear
+				line(730, 250, 780, 150, false),
+				line(780, 150, 830, 250, false),
+				// Head top
+				line(550, 250, 730, 250, false),
 				// Cheeks
-				walls = append(walls, genLine(450, 250, 400, 400, false)...)
-				walls = append(walls, genLine(830, 250, 880, 400, false)...)
+				line(450, 250, 400, 400, false),
+				line(830, 250, 880, 400, false),
 				// Chin
-				walls = append(walls, genLine(400, 400, 640, 600, false)...)
-				walls = append(walls, genLine(880, 400, 640, 600, false)...)
+				line(400, 400, 640, 600, false),
+				line(880, 400, 640, 600, false),
 				// Whiskers
-				walls = append(walls, genLine(350, 380, 250, 350, true)...)
-				walls = append(walls, genLine(350, 400, 250, 400, true)...)
-				walls = append(walls, genLine(930, 380, 1030, 350, true)...)
-				walls = append(walls, genLine(930, 400, 1030, 400, true)...)
-				return walls
-			}(),
+				line(350, 380, 250, 350, true),
+				line(350, 400, 250, 400, true),
+				line(930, 380, 1030, 350, true),
+				line(930, 400, 1030, 400, true),
+			),
 			Memory: MemoryNode{
 				Position: core.Vector2{X: 640, Y: 420},
 				Title:    "Our Little Family",
@@ -135,26 +141,17 @@ func InitLevels() []Level {
 				Color:  color.RGBA{255, 200, 100, 255},
 				Photos: []string{"assets/tonton1.jpg", "assets/tonton2.jpg", "assets/tonton3.jpg", "assets/tonton4.jpg", "assets/tonton5.jpg"},
 			},
-			StartP1:  core.Vector2{X: 640, Y: 100},
-			StartP2:  core.Vector2{X: 640, Y: 650},
-			Friction: 0.95,
+			StartP1:     core.Vector2{X: 640, Y: 100},
+			StartP2:     core.Vector2{X: 640, Y: 650},
+			Friction:    0.95,
+			Personality: "hider",
 		},
 		// 4. The Beautiful Mess: Explosive Chaos (Checkerboard grid)
 		{
 			Name:  "The Beautiful Mess",
 			Wells: []GravityWell{{Position: core.Vector2{X: 640, Y: 360}, Radius: 100, Mass: 3.0}},
-			Walls: func() []WallDef {
-				var walls []WallDef
-				// Checkerboard pattern reduces entity count by 50% while looking "messy"
-				for x := 0; x < 42; x++ {
-					for y := 0; y < 24; y++ {
-						if (x+y)%2 == 0 {
-							walls = append(walls, WallDef{X: float64(x*30 + 15), Y: float64(y*30 + 15), Destructible: true})
-						}
-					}
-				}
-				return walls
-			}(),
+			// Checkerboard pattern reduces entity count by 50% while looking "messy"
+			Walls: walls(ShapeDef{Type: "checkerboard", Position: core.Vector2{X: 15, Y: 15}, Cols: 42, Rows: 24, Step: 30, Destructible: true}),
 			Memory: MemoryNode{
 				Position: core.Vector2{X: 640, Y: 360},
 				Title:    "Pure Comfort",
@@ -164,9 +161,10 @@ func InitLevels() []Level {
 				Color:  color.RGBA{255, 100, 100, 255},
 				Photos: []string{"assets/forkU.jpg", "assets/burrito.jpg", "assets/ToeSuckah.jpg", "assets/passedawazoo.jpg"},
 			},
-			StartP1:  core.Vector2{X: 100, Y: 100},
-			StartP2:  core.Vector2{X: 1180, Y: 620},
-			Friction: 0.90, // Heavy feel
+			StartP1:     core.Vector2{X: 100, Y: 100},
+			StartP2:     core.Vector2{X: 1180, Y: 620},
+			Friction:    0.90, // Heavy feel
+			Personality: "reckless",
 		},
 		// 5. Grounded in the Storm: Hurricane Twist (Corner wells pushing in)
 		{
@@ -177,7 +175,7 @@ func InitLevels() []Level {
 				{Position: core.Vector2{X: 0, Y: 720}, Radius: 200, Mass: 4.0},
 				{Position: core.Vector2{X: 1280, Y: 720}, Radius: 200, Mass: 4.0},
 			},
-			Walls: append(genLine(640, 0, 640, 300, false), genLine(640, 420, 640, 720, false)...),
+			Walls: walls(line(640, 0, 640, 300, false), line(640, 420, 640, 720, false)),
 			Memory: MemoryNode{
 				Position: core.Vector2{X: 640, Y: 360},
 				Title:    "Our Sanctuary",
@@ -187,9 +185,10 @@ func InitLevels() []Level {
 				Color:  color.RGBA{100, 100, 255, 255},
 				Photos: []string{"assets/hugtree.jpg", "assets/warmth.jpg", "assets/unicorn.jpg"},
 			},
-			StartP1:  core.Vector2{X: 100, Y: 360},
-			StartP2:  core.Vector2{X: 1180, Y: 360},
-			Friction: 0.94,
+			StartP1:     core.Vector2{X: 100, Y: 360},
+			StartP2:     core.Vector2{X: 1180, Y: 360},
+			Friction:    0.94,
+			Personality: "hider",
 		},
 		// 6. The Constant Duo: Orbits Twist (Low friction spinning)
 		{
@@ -206,24 +205,22 @@ func InitLevels() []Level {
 				Color:  color.RGBA{200, 200, 255, 255},
 				Photos: []string{"assets/duo.jpg", "assets/duo2.jpg", "assets/duo3.jpg", "assets/duo4.jpg"},
 			},
-			StartP1:  core.Vector2{X: 640, Y: 100},
-			StartP2:  core.Vector2{X: 640, Y: 620},
-			Friction: 0.99, // Orbital feel
+			StartP1:     core.Vector2{X: 640, Y: 100},
+			StartP2:     core.Vector2{X: 640, Y: 620},
+			Friction:    0.99, // Orbital feel
+			Personality: "playful",
 		},
 		// 7. The Magnum Opus: Event Horizon Twist (Indestructible wall gap)
 		{
 			Name:  "The Magnum Opus",
 			Wells: []GravityWell{{Position: core.Vector2{X: 640, Y: 360}, Radius: 300, Mass: 8.0}},
-			Walls: func() []WallDef {
-				var walls []WallDef
-				// Indestructible diamond shield
-				walls = append(walls, genLine(640, 200, 800, 360, false)...)
-				walls = append(walls, genLine(800, 360, 640, 520, false)...)
-				walls = append(walls, genLine(640, 520, 480, 360, false)...)
-				// The only gap is at the top left
-				walls = append(walls, genLine(480, 360, 600, 240, false)...)
-				return walls
-			}(),
+			// Indestructible diamond shield; the only gap is at the top left
+			Walls: walls(
+				line(640, 200, 800, 360, false),
+				line(800, 360, 640, 520, false),
+				line(640, 520, 480, 360, false),
+				line(480, 360, 600, 240, false),
+			),
 			Memory: MemoryNode{
 				Position: core.Vector2{X: 640, Y: 360},
 				Title:    "My Goddess",
@@ -233,9 +230,10 @@ func InitLevels() []Level {
 				Color:  color.RGBA{255, 255, 100, 255},
 				Photos: []string{"assets/magnumOpus.jpg", "assets/kewtcrunch.jpg", "assets/sitkewt.jpg"},
 			},
-			StartP1:  core.Vector2{X: 100, Y: 100},
-			StartP2:  core.Vector2{X: 1180, Y: 100},
-			Friction: 0.94,
+			StartP1:     core.Vector2{X: 100, Y: 100},
+			StartP2:     core.Vector2{X: 1180, Y: 100},
+			Friction:    0.94,
+			Personality: "reckless",
 		},
 		// 8. Interlinked: Zero State Twist (Inevitable pull)
 		{
@@ -252,13 +250,17 @@ func InitLevels() []Level {
 				Color:  color.RGBA{255, 255, 255, 255},
 				Photos: []string{"assets/interlinked.jpg"},
 			},
-			StartP1:  core.Vector2{X: 200, Y: 360},
-			StartP2:  core.Vector2{X: 1080, Y: 360},
-			Friction: 0.96,
+			StartP1:     core.Vector2{X: 200, Y: 360},
+			StartP2:     core.Vector2{X: 1080, Y: 360},
+			Friction:    0.96,
+			Personality: "wary",
 		},
 	}
+	// Hand-placed chapters still carry a resolved seed so every level reports one consistently
+	for i := range levels {
+		if levels[i].Seed == 0 {
+			levels[i].Seed = ResolveSeed(seed, 0)
+		}
+	}
+	return levels
 }
-
-
-
-
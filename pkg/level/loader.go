@@ -0,0 +1,201 @@
+package level
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadError pinpoints a problem inside a level file so writers can fix data without reading Go
+type LoadError struct {
+	File         string
+	Line, Column int    // Zero when the problem was found after decoding (e.g. validation)
+	Field        string // Dotted JSON path of the offending value, if known
+	Err          error
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", e.Line, e.Column)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ": field %q", e.Field)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *LoadError) Unwrap() error { return e.Err }
+
+// levelFile is the on-disk layout: a Level plus generator sections that expand at load time
+type levelFile struct {
+	Level
+	Shapes       []ShapeDef  `json:"shapes,omitempty"`
+	Mask         *MaskDef    `json:"mask,omitempty"`
+	ScatterWells *ScatterDef `json:"scatter_wells,omitempty"`
+}
+
+// LoadLevels reads every *.json file in dir. Files are ordered by name, so a numeric
+// prefix ("01-the-spark.json") fixes the chapter order without a separate manifest.
+// A non-zero seed overrides every level's own seed (see ResolveSeed).
+func LoadLevels(dir string, seed int64) ([]Level, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return nil, statErr
+		}
+		return nil, fmt.Errorf("level: no level files in %s", dir)
+	}
+	sort.Strings(paths)
+
+	levels := make([]Level, 0, len(paths))
+	for _, path := range paths {
+		lvl, err := LoadLevelFile(path, seed)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, lvl)
+	}
+	return levels, nil
+}
+
+// LoadLevelFile decodes and validates a single level file, expanding procedural sections with the level's seed
+func LoadLevelFile(path string, seed int64) (Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Level{}, err
+	}
+
+	var lf levelFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// Rejecting unknown keys catches typos like "frction" that would otherwise silently use defaults
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&lf); err != nil {
+		return Level{}, decodeError(path, data, dec, err)
+	}
+
+	if err := lf.validate(); err != nil {
+		return Level{}, &LoadError{File: path, Field: err.field, Err: err}
+	}
+
+	lvl := lf.Level
+	lvl.Seed = ResolveSeed(seed, lf.Seed)
+	rng := NewRNG(lvl.Seed)
+
+	for i, shape := range lf.Shapes {
+		walls, err := shape.Expand()
+		if err != nil {
+			var se *ShapeError
+			if errors.As(err, &se) {
+				return Level{}, &LoadError{File: path, Field: fmt.Sprintf("shapes[%d].%s", i, se.Field), Err: errors.New(se.Msg)}
+			}
+			return Level{}, &LoadError{File: path, Field: fmt.Sprintf("shapes[%d]", i), Err: err}
+		}
+		lvl.Walls = append(lvl.Walls, walls...)
+	}
+	if m := lf.Mask; m != nil {
+		layout, err := LoadMask(filepath.Join(filepath.Dir(path), m.Path), *m)
+		if err != nil {
+			return Level{}, &LoadError{File: path, Field: "mask.path", Err: err}
+		}
+		lvl.Walls = append(lvl.Walls, layout.Walls...)
+		lvl.Wells = append(lvl.Wells, layout.Wells...)
+		// Painted spawn markers take precedence over start_p1/start_p2 in the file
+		if layout.StartP1 != nil {
+			lvl.StartP1 = *layout.StartP1
+		}
+		if layout.StartP2 != nil {
+			lvl.StartP2 = *layout.StartP2
+		}
+	}
+	if sc := lf.ScatterWells; sc != nil {
+		lvl.Wells = append(lvl.Wells, sc.Scatter(rng)...)
+	}
+	return lvl, nil
+}
+
+func decodeError(path string, data []byte, dec *json.Decoder, err error) error {
+	le := &LoadError{File: path, Err: err}
+	offset := dec.InputOffset()
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset, le.Field = typeErr.Offset, typeErr.Field
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		le.Field = strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		// The decoder has already consumed the value, so point back at the key itself
+		if at := bytes.LastIndex(data[:offset], []byte(`"`+le.Field+`"`)); at >= 0 {
+			offset = int64(at)
+		}
+	}
+	le.Line, le.Column = lineCol(data, offset)
+	return le
+}
+
+func lineCol(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte{'\n'}) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+type fieldError struct {
+	field, msg string
+}
+
+func (e *fieldError) Error() string { return e.msg }
+
+func (lf *levelFile) validate() *fieldError {
+	if lf.Name == "" {
+		return &fieldError{"name", "level needs a name"}
+	}
+	// Zero keeps the default feel; anything above 1 would add energy every tick
+	if lf.Friction < 0 || lf.Friction > 1 {
+		return &fieldError{"friction", fmt.Sprintf("must be in [0, 1], got %v", lf.Friction)}
+	}
+	for i, w := range lf.Wells {
+		if w.Radius <= 0 {
+			return &fieldError{fmt.Sprintf("wells[%d].radius", i), "must be positive"}
+		}
+	}
+	if sc := lf.ScatterWells; sc != nil {
+		if sc.Count < 0 {
+			return &fieldError{"scatter_wells.count", "must not be negative"}
+		}
+		if sc.Radius <= 0 {
+			return &fieldError{"scatter_wells.radius", "must be positive"}
+		}
+	}
+	if m := lf.Mask; m != nil {
+		if m.Path == "" {
+			return &fieldError{"mask.path", "mask needs an image path"}
+		}
+		if m.WellRadius < 0 {
+			return &fieldError{"mask.well_radius", "must not be negative"}
+		}
+	}
+	if len(lf.Memory.Photos) == 0 {
+		return &fieldError{"memory.photos", "a memory needs at least one photo"}
+	}
+	if len(lf.Memory.Descriptions) == 0 {
+		return &fieldError{"memory.descriptions", "a memory needs at least one description"}
+	}
+	return nil
+}
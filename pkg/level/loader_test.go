@@ -0,0 +1,134 @@
+package level
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestShippedLevelsMatchBuiltins(t *testing.T) {
+	const seed = 42
+	loaded, err := LoadLevels("../../levels", seed)
+	if err != nil {
+		t.Fatalf("LoadLevels() error = %v", err)
+	}
+	builtin := InitLevels(seed)
+	if len(loaded) != len(builtin) {
+		t.Fatalf("LoadLevels() returned %d levels, want %d", len(loaded), len(builtin))
+	}
+
+	for i := range builtin {
+		if !reflect.DeepEqual(loaded[i], builtin[i]) {
+			t.Errorf("level %d (%s) differs from InitLevels", i, builtin[i].Name)
+		}
+	}
+}
+
+// testdata/baseline_walls.json holds the wall lists the original hand-written
+// InitLevels produced. The shape primitives must reproduce them exactly, in
+// order, so collisions and the destructible layout play the same as before.
+func TestBuiltinWallsMatchBaseline(t *testing.T) {
+	data, err := os.ReadFile("testdata/baseline_walls.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var baseline []struct {
+		Name  string    `json:"name"`
+		Walls []WallDef `json:"walls"`
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		t.Fatal(err)
+	}
+
+	builtin := InitLevels(42)
+	if len(builtin) != len(baseline) {
+		t.Fatalf("InitLevels() returned %d levels, want %d", len(builtin), len(baseline))
+	}
+	for i, want := range baseline {
+		got := builtin[i]
+		if got.Name != want.Name {
+			t.Errorf("level %d name = %q, want %q", i, got.Name, want.Name)
+		}
+		if len(got.Walls) != len(want.Walls) {
+			t.Errorf("%s: %d walls, want %d", want.Name, len(got.Walls), len(want.Walls))
+			continue
+		}
+		for j := range want.Walls {
+			if got.Walls[j] != want.Walls[j] {
+				t.Errorf("%s: wall %d = %+v, want %+v", want.Name, j, got.Walls[j], want.Walls[j])
+				break
+			}
+		}
+	}
+}
+
+func TestSeedReproducesLayout(t *testing.T) {
+	a, b, c := InitLevels(7), InitLevels(7), InitLevels(8)
+	if !reflect.DeepEqual(a[1].Wells, b[1].Wells) {
+		t.Error("same seed produced different well layouts")
+	}
+	if reflect.DeepEqual(a[1].Wells, c[1].Wells) {
+		t.Error("different seeds produced identical well layouts")
+	}
+
+	unseeded := InitLevels(0)
+	if unseeded[1].Seed == 0 {
+		t.Fatal("InitLevels(0) left the level without a resolved seed")
+	}
+	if replay := InitLevels(unseeded[1].Seed); !reflect.DeepEqual(replay[1].Wells, unseeded[1].Wells) {
+		t.Error("reported seed does not reproduce the random layout")
+	}
+}
+
+func TestLoadLevelFileErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantLine  int
+		wantField string
+	}{
+		{
+			name:     "Syntax error",
+			data:     "{\n  \"name\": \"x\",\n  \"friction\" 0.9\n}",
+			wantLine: 3,
+		},
+		{
+			name:      "Wrong type",
+			data:      "{\n  \"name\": \"x\",\n  \"friction\": \"slippery\"\n}",
+			wantLine:  3,
+			wantField: "friction",
+		},
+		{
+			name:      "Unknown field",
+			data:      "{\n  \"name\": \"x\",\n  \"frction\": 0.9\n}",
+			wantLine:  3,
+			wantField: "frction",
+		},
+		{
+			name:      "Validation",
+			data:      `{"name": "x", "friction": 0.9, "wells": [{"radius": -1}]}`,
+			wantField: "wells[0].radius",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "level.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadLevelFile(path, 0)
+			var le *LoadError
+			if !errors.As(err, &le) {
+				t.Fatalf("LoadLevelFile() error = %v, want *LoadError", err)
+			}
+			if le.Line != tt.wantLine || le.Field != tt.wantField {
+				t.Errorf("LoadLevelFile() line %d field %q, want line %d field %q (%v)", le.Line, le.Field, tt.wantLine, tt.wantField, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package level
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"math"
+	"os"
+
+	"beautifulmess/pkg/core"
+)
+
+// MaskCell is the world-space footprint of one mask cell; it matches the wall entity size
+const MaskCell = 10
+
+// MaskDef lets designers paint a layout in an image editor instead of listing coordinates
+type MaskDef struct {
+	Path       string       `json:"path"`                  // Relative to the level file
+	Palette    *MaskPalette `json:"palette,omitempty"`     // Defaults to DefaultMaskPalette
+	WellRadius float64      `json:"well_radius,omitempty"` // Zero derives the radius from the painted blob
+	WellMass   float64      `json:"well_mass,omitempty"`   // Defaults to 1
+}
+
+// MaskPalette maps painted colours to level features. Pixels that match none of them are empty space.
+type MaskPalette struct {
+	Wall         color.RGBA `json:"wall"`
+	Destructible color.RGBA `json:"destructible"`
+	Well         color.RGBA `json:"well"`
+	StartP1      color.RGBA `json:"start_p1"`
+	StartP2      color.RGBA `json:"start_p2"`
+}
+
+// DefaultMaskPalette reuses the in-game wall colours so a mask looks like the level it produces
+var DefaultMaskPalette = MaskPalette{
+	Wall:         color.RGBA{0, 255, 255, 255},
+	Destructible: color.RGBA{255, 150, 50, 255},
+	Well:         color.RGBA{255, 0, 255, 255},
+	StartP1:      color.RGBA{0, 255, 0, 255},
+	StartP2:      color.RGBA{255, 0, 0, 255},
+}
+
+type maskClass uint8
+
+const (
+	maskEmpty maskClass = iota
+	maskWall
+	maskDestructible
+	maskWell
+	maskP1
+	maskP2
+	maskClasses
+)
+
+// Anti-aliased brush edges land near, not on, a palette colour; this tolerance absorbs them
+const maskColorTolerance = 60
+
+// MaskLayout is everything extracted from a mask image
+type MaskLayout struct {
+	Walls            []WallDef
+	Wells            []GravityWell
+	StartP1, StartP2 *core.Vector2 // Nil when the mask does not mark a spawn
+}
+
+// LoadMask decodes the image at path and downsamples it onto the wall grid
+func LoadMask(path string, def MaskDef) (MaskLayout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return MaskLayout{}, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return MaskLayout{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return MaskFromImage(img, def), nil
+}
+
+// MaskFromImage stretches img over the whole world, so any resolution works; a 128x72 image maps 1:1 onto cells
+func MaskFromImage(img image.Image, def MaskDef) MaskLayout {
+	pal := DefaultMaskPalette
+	if def.Palette != nil {
+		pal = *def.Palette
+	}
+	const cols, rows = core.ScreenWidth / MaskCell, core.ScreenHeight / MaskCell
+	b := img.Bounds()
+
+	var grid [cols][rows]maskClass
+	for cx := 0; cx < cols; cx++ {
+		for cy := 0; cy < rows; cy++ {
+			// Any painted pixel claims the cell so one-pixel strokes survive downsampling; the most common paint wins
+			x0, x1 := b.Min.X+cx*b.Dx()/cols, b.Min.X+(cx+1)*b.Dx()/cols
+			y0, y1 := b.Min.Y+cy*b.Dy()/rows, b.Min.Y+(cy+1)*b.Dy()/rows
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+			if y1 == y0 {
+				y1 = y0 + 1
+			}
+			var votes [maskClasses]int
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					votes[classify(img.At(x, y), &pal)]++
+				}
+			}
+			best := maskEmpty
+			for c := maskClass(1); c < maskClasses; c++ {
+				if votes[c] > 0 && (best == maskEmpty || votes[c] > votes[best]) {
+					best = c
+				}
+			}
+			grid[cx][cy] = best
+		}
+	}
+
+	var out MaskLayout
+	var seen [cols][rows]bool
+	var p1, p2 []core.Vector2
+	for cx := 0; cx < cols; cx++ {
+		for cy := 0; cy < rows; cy++ {
+			centre := core.Vector2{X: float64(cx*MaskCell) + MaskCell/2, Y: float64(cy*MaskCell) + MaskCell/2}
+			switch grid[cx][cy] {
+			case maskWall:
+				out.Walls = append(out.Walls, WallDef{X: centre.X, Y: centre.Y})
+			case maskDestructible:
+				out.Walls = append(out.Walls, WallDef{X: centre.X, Y: centre.Y, Destructible: true})
+			case maskP1:
+				p1 = append(p1, centre)
+			case maskP2:
+				p2 = append(p2, centre)
+			case maskWell:
+				if seen[cx][cy] {
+					continue
+				}
+				// Each connected blob of well paint becomes one well at its centroid
+				var sum core.Vector2
+				n := 0
+				stack := [][2]int{{cx, cy}}
+				seen[cx][cy] = true
+				for len(stack) > 0 {
+					c := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					sum.X += float64(c[0]*MaskCell) + MaskCell/2
+					sum.Y += float64(c[1]*MaskCell) + MaskCell/2
+					n++
+					for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+						nx, ny := c[0]+d[0], c[1]+d[1]
+						if nx < 0 || nx >= cols || ny < 0 || ny >= rows || seen[nx][ny] || grid[nx][ny] != maskWell {
+							continue
+						}
+						seen[nx][ny] = true
+						stack = append(stack, [2]int{nx, ny})
+					}
+				}
+				radius := def.WellRadius
+				if radius == 0 {
+					radius = math.Sqrt(float64(n)/math.Pi) * MaskCell
+				}
+				mass := def.WellMass
+				if mass == 0 {
+					mass = 1.0
+				}
+				out.Wells = append(out.Wells, GravityWell{
+					Position: core.Vector2{X: sum.X / float64(n), Y: sum.Y / float64(n)},
+					Radius:   radius, Mass: mass,
+				})
+			}
+		}
+	}
+	out.StartP1, out.StartP2 = centroid(p1), centroid(p2)
+	return out
+}
+
+func classify(c color.Color, pal *MaskPalette) maskClass {
+	px := color.NRGBAModel.Convert(c).(color.NRGBA)
+	// Transparent pixels are empty regardless of their colour channels
+	if px.A < 128 {
+		return maskEmpty
+	}
+	candidates := [...]struct {
+		col   color.RGBA
+		class maskClass
+	}{
+		{pal.Wall, maskWall},
+		{pal.Destructible, maskDestructible},
+		{pal.Well, maskWell},
+		{pal.StartP1, maskP1},
+		{pal.StartP2, maskP2},
+	}
+	best, bestDist := maskEmpty, maskColorTolerance*maskColorTolerance+1
+	for _, cand := range candidates {
+		dr, dg, db := int(px.R)-int(cand.col.R), int(px.G)-int(cand.col.G), int(px.B)-int(cand.col.B)
+		if d := dr*dr + dg*dg + db*db; d < bestDist {
+			best, bestDist = cand.class, d
+		}
+	}
+	return best
+}
+
+func centroid(pts []core.Vector2) *core.Vector2 {
+	if len(pts) == 0 {
+		return nil
+	}
+	var c core.Vector2
+	for _, p := range pts {
+		c.X += p.X
+		c.Y += p.Y
+	}
+	c.X /= float64(len(pts))
+	c.Y /= float64(len(pts))
+	return &c
+}
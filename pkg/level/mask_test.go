@@ -0,0 +1,102 @@
+package level
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMask(t *testing.T) {
+	// A 1:1 mask: every pixel is one 10px wall cell
+	img := image.NewNRGBA(image.Rect(0, 0, 128, 72))
+	pal := DefaultMaskPalette
+	for x := 10; x < 20; x++ {
+		img.Set(x, 5, pal.Wall)
+	}
+	img.Set(30, 5, pal.Destructible)
+	for x := 60; x < 64; x++ {
+		for y := 30; y < 34; y++ {
+			img.Set(x, y, pal.Well)
+		}
+	}
+	img.Set(2, 2, pal.StartP1)
+	img.Set(120, 70, pal.StartP2)
+	// Slightly off-palette paint still reads as a wall
+	img.Set(40, 40, color.RGBA{10, 240, 250, 255})
+
+	path := filepath.Join(t.TempDir(), "mask.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	layout, err := LoadMask(path, MaskDef{WellMass: 2})
+	if err != nil {
+		t.Fatalf("LoadMask() error = %v", err)
+	}
+
+	destructible := 0
+	for _, w := range layout.Walls {
+		if w.Destructible {
+			destructible++
+		}
+	}
+	if len(layout.Walls) != 12 || destructible != 1 {
+		t.Errorf("walls = %d (%d destructible), want 12 (1 destructible)", len(layout.Walls), destructible)
+	}
+	if len(layout.Wells) != 1 {
+		t.Fatalf("wells = %d, want 1", len(layout.Wells))
+	}
+	if got := layout.Wells[0]; got.Position.X != 620 || got.Position.Y != 320 || got.Mass != 2 {
+		t.Errorf("well = %+v, want centroid (620, 320) with mass 2", got)
+	}
+	if layout.StartP1 == nil || layout.StartP1.X != 25 || layout.StartP1.Y != 25 {
+		t.Errorf("StartP1 = %v, want (25, 25)", layout.StartP1)
+	}
+	if layout.StartP2 == nil || layout.StartP2.X != 1205 || layout.StartP2.Y != 705 {
+		t.Errorf("StartP2 = %v, want (1205, 705)", layout.StartP2)
+	}
+}
+
+func TestMaskKeepsThinStrokes(t *testing.T) {
+	// At full resolution every cell covers 10x10 pixels, so a one-pixel line is 10% paint
+	img := image.NewNRGBA(image.Rect(0, 0, 1280, 720))
+	pal := DefaultMaskPalette
+	for x := 100; x < 200; x++ {
+		img.Set(x, 55, pal.Wall)
+	}
+	for y := 300; y < 350; y++ {
+		img.Set(805, y, pal.Destructible)
+	}
+	// A cell holding more of one paint than another takes the larger share
+	for y := 600; y < 610; y++ {
+		img.Set(600, y, pal.Wall)
+		img.Set(601, y, pal.Destructible)
+		img.Set(602, y, pal.Destructible)
+	}
+
+	layout := MaskFromImage(img, MaskDef{})
+	want := map[WallDef]bool{{X: 605, Y: 605, Destructible: true}: true}
+	for x := 105.0; x < 200; x += MaskCell {
+		want[WallDef{X: x, Y: 55}] = true
+	}
+	for y := 305.0; y < 350; y += MaskCell {
+		want[WallDef{X: 805, Y: y, Destructible: true}] = true
+	}
+
+	if len(layout.Walls) != len(want) {
+		t.Errorf("walls = %d, want %d", len(layout.Walls), len(want))
+	}
+	for _, w := range layout.Walls {
+		if !want[w] {
+			t.Errorf("unexpected wall %+v", w)
+		}
+	}
+}
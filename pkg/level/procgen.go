@@ -0,0 +1,50 @@
+package level
+
+import (
+	"math/rand"
+
+	"beautifulmess/pkg/core"
+)
+
+// ResolveSeed picks the seed a level is generated with: an explicit override (e.g. from the
+// command line) wins, then the level's own seed, then a fresh random one. The result is never
+// zero, so it can always be logged and fed back as an override to reproduce a layout.
+func ResolveSeed(override, own int64) int64 {
+	if override != 0 {
+		return override
+	}
+	if own != 0 {
+		return own
+	}
+	for {
+		if s := rand.Int63(); s != 0 {
+			return s
+		}
+	}
+}
+
+// NewRNG gives each level a private stream so generating one chapter never perturbs another
+func NewRNG(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// ScatterDef describes wells placed at random inside a rectangle, keeping "kaleidoscope" layouts data-driven
+type ScatterDef struct {
+	Count  int          `json:"count"`
+	Min    core.Vector2 `json:"min"`
+	Max    core.Vector2 `json:"max"`
+	Radius float64      `json:"radius"`
+	Mass   float64      `json:"mass"`
+}
+
+// Scatter places the wells using rng, so the same seed always yields the same layout
+func (sc ScatterDef) Scatter(rng *rand.Rand) []GravityWell {
+	wells := make([]GravityWell, 0, sc.Count)
+	for i := 0; i < sc.Count; i++ {
+		wells = append(wells, GravityWell{
+			Position: core.Vector2{X: sc.Min.X + rng.Float64()*(sc.Max.X-sc.Min.X), Y: sc.Min.Y + rng.Float64()*(sc.Max.Y-sc.Min.Y)},
+			Radius:   sc.Radius, Mass: sc.Mass,
+		})
+	}
+	return wells
+}
@@ -0,0 +1,280 @@
+package level
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"beautifulmess/pkg/core"
+)
+
+// DefaultWallStep matches the 10px footprint of a wall entity, so shapes read as solid lines
+const DefaultWallStep = 10.0
+
+// ShapeDef is a declarative wall primitive. Only the fields relevant to Type are read:
+//
+//	line          points[2]
+//	polyline      points[2+], closed
+//	rect          position (top-left), size
+//	fill_rect     position (top-left), size
+//	circle        position (centre), radius
+//	arc           position (centre), radius, from, to (degrees, clockwise from +X)
+//	checkerboard  position (first cell), cols, rows
+//	text          position (top-left), text
+type ShapeDef struct {
+	Type         string         `json:"type"`
+	Points       []core.Vector2 `json:"points,omitempty"`
+	Closed       bool           `json:"closed,omitempty"`
+	Position     core.Vector2   `json:"position"`
+	Size         core.Vector2   `json:"size"`
+	Radius       float64        `json:"radius,omitempty"`
+	From         float64        `json:"from,omitempty"`
+	To           float64        `json:"to,omitempty"`
+	Cols         int            `json:"cols,omitempty"`
+	Rows         int            `json:"rows,omitempty"`
+	Text         string         `json:"text,omitempty"`
+	Step         float64        `json:"step,omitempty"` // Grid spacing between walls; defaults to DefaultWallStep
+	Destructible bool           `json:"destructible,omitempty"`
+}
+
+// ShapeError reports which part of a shape definition could not be expanded
+type ShapeError struct {
+	Field, Msg string
+}
+
+func (e *ShapeError) Error() string { return e.Field + ": " + e.Msg }
+
+// Expand converts the shape into grid-aligned wall placements
+func (s ShapeDef) Expand() ([]WallDef, error) {
+	step := s.Step
+	if step == 0 {
+		step = DefaultWallStep
+	}
+	if step < 0 {
+		return nil, &ShapeError{"step", "must be positive"}
+	}
+
+	switch s.Type {
+	case "line":
+		if len(s.Points) != 2 {
+			return nil, &ShapeError{"points", fmt.Sprintf("line needs exactly 2 points, got %d", len(s.Points))}
+		}
+		return Line(s.Points[0], s.Points[1], step, s.Destructible), nil
+	case "polyline":
+		if len(s.Points) < 2 {
+			return nil, &ShapeError{"points", fmt.Sprintf("polyline needs at least 2 points, got %d", len(s.Points))}
+		}
+		return Polyline(s.Points, s.Closed, step, s.Destructible), nil
+	case "rect":
+		if s.Size.X <= 0 || s.Size.Y <= 0 {
+			return nil, &ShapeError{"size", "must be positive"}
+		}
+		return Rect(s.Position, s.Size, step, s.Destructible), nil
+	case "fill_rect":
+		if s.Size.X <= 0 || s.Size.Y <= 0 {
+			return nil, &ShapeError{"size", "must be positive"}
+		}
+		return FillRect(s.Position, s.Size, step, s.Destructible), nil
+	case "circle":
+		if s.Radius <= 0 {
+			return nil, &ShapeError{"radius", "must be positive"}
+		}
+		return Arc(s.Position, s.Radius, 0, 360, step, s.Destructible), nil
+	case "arc":
+		if s.Radius <= 0 {
+			return nil, &ShapeError{"radius", "must be positive"}
+		}
+		if s.From == s.To {
+			return nil, &ShapeError{"to", "arc must span a non-zero angle"}
+		}
+		return Arc(s.Position, s.Radius, s.From, s.To, step, s.Destructible), nil
+	case "checkerboard":
+		if s.Cols <= 0 || s.Rows <= 0 {
+			return nil, &ShapeError{"cols", "cols and rows must be positive"}
+		}
+		return Checkerboard(s.Position, s.Cols, s.Rows, step, s.Destructible), nil
+	case "text":
+		walls, err := Text(s.Position, s.Text, step, s.Destructible)
+		if err != nil {
+			return nil, &ShapeError{"text", err.Error()}
+		}
+		return walls, nil
+	case "":
+		return nil, &ShapeError{"type", "missing shape type"}
+	}
+	return nil, &ShapeError{"type", fmt.Sprintf("unknown shape %q", s.Type)}
+}
+
+// Line places walls from a to b inclusive, one every step along the dominant axis
+func Line(a, b core.Vector2, step float64, dest bool) []WallDef {
+	steps := int(math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y)) / step)
+	// Degenerate segments would otherwise divide by zero and yield NaN positions
+	if steps == 0 {
+		return []WallDef{{X: a.X, Y: a.Y, Destructible: dest}}
+	}
+	walls := make([]WallDef, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		walls = append(walls, WallDef{X: a.X + t*(b.X-a.X), Y: a.Y + t*(b.Y-a.Y), Destructible: dest})
+	}
+	return walls
+}
+
+// Polyline joins consecutive points; shared corners are emitted once to avoid stacked wall entities
+func Polyline(points []core.Vector2, closed bool, step float64, dest bool) []WallDef {
+	var walls []WallDef
+	n := len(points)
+	segments := n - 1
+	if closed {
+		segments = n
+	}
+	for i := 0; i < segments; i++ {
+		seg := Line(points[i], points[(i+1)%n], step, dest)
+		if i > 0 {
+			seg = seg[1:]
+		}
+		// The closing segment ends where the first one started
+		if closed && i == segments-1 && len(seg) > 0 {
+			seg = seg[:len(seg)-1]
+		}
+		walls = append(walls, seg...)
+	}
+	return walls
+}
+
+// Rect outlines an axis-aligned rectangle given its top-left corner
+func Rect(pos, size core.Vector2, step float64, dest bool) []WallDef {
+	return Polyline([]core.Vector2{
+		pos,
+		{X: pos.X + size.X, Y: pos.Y},
+		{X: pos.X + size.X, Y: pos.Y + size.Y},
+		{X: pos.X, Y: pos.Y + size.Y},
+	}, true, step, dest)
+}
+
+// FillRect covers the rectangle with a solid block of walls
+func FillRect(pos, size core.Vector2, step float64, dest bool) []WallDef {
+	cols, rows := int(size.X/step), int(size.Y/step)
+	walls := make([]WallDef, 0, (cols+1)*(rows+1))
+	for x := 0; x <= cols; x++ {
+		for y := 0; y <= rows; y++ {
+			walls = append(walls, WallDef{X: pos.X + float64(x)*step, Y: pos.Y + float64(y)*step, Destructible: dest})
+		}
+	}
+	return walls
+}
+
+// Arc traces the circle of radius r around centre from one angle to another, in degrees
+func Arc(centre core.Vector2, r, from, to, step float64, dest bool) []WallDef {
+	span := (to - from) * math.Pi / 180
+	full := math.Abs(to-from) >= 360
+	// Spacing walls by arc length keeps large and small circles equally dense
+	n := int(math.Ceil(math.Abs(span) * r / step))
+	if n < 1 {
+		n = 1
+	}
+	last := n
+	if full {
+		last = n - 1 // The end point of a full turn coincides with the start
+	}
+	start := from * math.Pi / 180
+	walls := make([]WallDef, 0, last+1)
+	for i := 0; i <= last; i++ {
+		a := start + span*float64(i)/float64(n)
+		walls = append(walls, WallDef{X: centre.X + math.Cos(a)*r, Y: centre.Y + math.Sin(a)*r, Destructible: dest})
+	}
+	return walls
+}
+
+// Checkerboard fills alternating cells, starting with the cell at pos
+func Checkerboard(pos core.Vector2, cols, rows int, step float64, dest bool) []WallDef {
+	var walls []WallDef
+	for x := 0; x < cols; x++ {
+		for y := 0; y < rows; y++ {
+			if (x+y)%2 == 0 {
+				walls = append(walls, WallDef{X: pos.X + float64(x)*step, Y: pos.Y + float64(y)*step, Destructible: dest})
+			}
+		}
+	}
+	return walls
+}
+
+// Text stamps a string using the built-in 3x5 glyph set; each lit pixel becomes one wall
+func Text(pos core.Vector2, s string, step float64, dest bool) ([]WallDef, error) {
+	var walls []WallDef
+	cursor := 0
+	for _, r := range strings.ToUpper(s) {
+		glyph, ok := glyphs[r]
+		if !ok {
+			return nil, fmt.Errorf("no glyph for %q", r)
+		}
+		for row, line := range glyph {
+			for col, c := range line {
+				if c != '#' {
+					continue
+				}
+				walls = append(walls, WallDef{
+					X:            pos.X + float64(cursor+col)*step,
+					Y:            pos.Y + float64(row)*step,
+					Destructible: dest,
+				})
+			}
+		}
+		// One blank column separates letters
+		cursor += glyphWidth + 1
+	}
+	return walls, nil
+}
+
+const glyphWidth = 3
+
+var glyphs = map[rune][5]string{
+	' ': {"...", "...", "...", "...", "..."},
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"##.", "..#", ".#.", "#..", "###"},
+	'3': {"##.", "..#", ".#.", "..#", "##."},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "##.", "..#", "##."},
+	'6': {".##", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", ".#.", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "##."},
+	'!': {".#.", ".#.", ".#.", "...", ".#."},
+	'?': {"##.", "..#", ".#.", "...", ".#."},
+	'.': {"...", "...", "...", "...", ".#."},
+	',': {"...", "...", "...", ".#.", "#.."},
+	'-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'\'': {".#.", ".#.", "...", "...", "..."},
+	'<': {"..#", ".#.", "#..", ".#.", "..#"},
+	'>': {"#..", ".#.", "..#", ".#.", "#.."},
+	'&': {".#.", "#.#", ".#.", "#.#", ".##"},
+}
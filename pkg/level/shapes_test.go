@@ -0,0 +1,126 @@
+package level
+
+import (
+	"math"
+	"testing"
+
+	"beautifulmess/pkg/core"
+)
+
+func TestShapeExpand(t *testing.T) {
+	pt := func(x, y float64) core.Vector2 { return core.Vector2{X: x, Y: y} }
+
+	tests := []struct {
+		name      string
+		shape     ShapeDef
+		wantCount int
+		wantFirst WallDef
+		wantLast  WallDef
+	}{
+		{
+			name:      "Line",
+			shape:     ShapeDef{Type: "line", Points: []core.Vector2{pt(300, 100), pt(980, 100)}},
+			wantCount: 69,
+			wantFirst: WallDef{X: 300, Y: 100},
+			wantLast:  WallDef{X: 980, Y: 100},
+		},
+		{
+			name:      "Degenerate line",
+			shape:     ShapeDef{Type: "line", Points: []core.Vector2{pt(5, 5), pt(5, 5)}},
+			wantCount: 1,
+			wantFirst: WallDef{X: 5, Y: 5},
+			wantLast:  WallDef{X: 5, Y: 5},
+		},
+		{
+			name:      "Polyline shares corners",
+			shape:     ShapeDef{Type: "polyline", Points: []core.Vector2{pt(0, 0), pt(100, 0), pt(100, 100)}, Destructible: true},
+			wantCount: 21,
+			wantFirst: WallDef{X: 0, Y: 0, Destructible: true},
+			wantLast:  WallDef{X: 100, Y: 100, Destructible: true},
+		},
+		{
+			name:      "Rect outline",
+			shape:     ShapeDef{Type: "rect", Position: pt(0, 0), Size: pt(100, 50)},
+			wantCount: 30,
+			wantFirst: WallDef{X: 0, Y: 0},
+			wantLast:  WallDef{X: 0, Y: 10},
+		},
+		{
+			name:      "Filled rect",
+			shape:     ShapeDef{Type: "fill_rect", Position: pt(0, 0), Size: pt(20, 20)},
+			wantCount: 9,
+			wantFirst: WallDef{X: 0, Y: 0},
+			wantLast:  WallDef{X: 20, Y: 20},
+		},
+		{
+			name:      "Circle",
+			shape:     ShapeDef{Type: "circle", Position: pt(100, 100), Radius: 50},
+			wantCount: 32, // ceil(2*pi*50/10) steps; the closing point is not repeated
+			wantFirst: WallDef{X: 150, Y: 100},
+		},
+		{
+			name:      "Checkerboard",
+			shape:     ShapeDef{Type: "checkerboard", Position: pt(15, 15), Cols: 4, Rows: 3, Step: 30},
+			wantCount: 6,
+			wantFirst: WallDef{X: 15, Y: 15},
+			wantLast:  WallDef{X: 105, Y: 45},
+		},
+		{
+			name:      "Text",
+			shape:     ShapeDef{Type: "text", Position: pt(0, 0), Text: "hi"},
+			wantCount: 20,
+			wantFirst: WallDef{X: 0, Y: 0},
+			wantLast:  WallDef{X: 60, Y: 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			walls, err := tt.shape.Expand()
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if len(walls) != tt.wantCount {
+				t.Fatalf("Expand() produced %d walls, want %d", len(walls), tt.wantCount)
+			}
+			if !nearWall(walls[0], tt.wantFirst) {
+				t.Errorf("first wall = %+v, want %+v", walls[0], tt.wantFirst)
+			}
+			if tt.wantLast != (WallDef{}) && !nearWall(walls[len(walls)-1], tt.wantLast) {
+				t.Errorf("last wall = %+v, want %+v", walls[len(walls)-1], tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestShapeExpandErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		shape     ShapeDef
+		wantField string
+	}{
+		{"Missing type", ShapeDef{}, "type"},
+		{"Unknown type", ShapeDef{Type: "spiral"}, "type"},
+		{"Line with one point", ShapeDef{Type: "line", Points: []core.Vector2{{}}}, "points"},
+		{"Negative step", ShapeDef{Type: "circle", Radius: 10, Step: -1}, "step"},
+		{"Empty arc", ShapeDef{Type: "arc", Radius: 10, From: 90, To: 90}, "to"},
+		{"Missing glyph", ShapeDef{Type: "text", Text: "~"}, "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.shape.Expand()
+			se, ok := err.(*ShapeError)
+			if !ok {
+				t.Fatalf("Expand() error = %v, want *ShapeError", err)
+			}
+			if se.Field != tt.wantField {
+				t.Errorf("Expand() field = %q, want %q", se.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func nearWall(a, b WallDef) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9 && a.Destructible == b.Destructible
+}
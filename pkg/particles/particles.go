@@ -6,9 +6,6 @@ import (
 	"math/rand"
 
 	"beautifulmess/pkg/core"
-
-	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 type ParticleQuirk int
@@ -19,6 +16,18 @@ const (
 	QuirkFlicker                // Particles that change size/alpha rapidly
 )
 
+var quirkNames = map[string]ParticleQuirk{
+	"standard": QuirkStandard,
+	"orbit":    QuirkOrbit,
+	"flicker":  QuirkFlicker,
+}
+
+// ParseQuirk looks a quirk up by the lowercase name scripts use
+func ParseQuirk(name string) (ParticleQuirk, bool) {
+	q, ok := quirkNames[name]
+	return q, ok
+}
+
 type Particle struct {
 	Position core.Vector2
 	Velocity core.Vector2
@@ -108,35 +117,7 @@ func (ps *ParticleSystem) Update() {
 	ps.particles = ps.particles[:n]
 }
 
-func (ps *ParticleSystem) Draw(screen *ebiten.Image) {
-	for _, p := range ps.particles {
-		// Alpha fade
-		c := p.Color
-		c.A = uint8(float64(c.A) * p.Life)
-		
-		DrawWrappedParticle(screen, p.Position, p.Size*p.Life, c)
-	}
-}
-
-// DrawWrappedParticle is a simplified version of DrawWrappedCircle for particles
-func DrawWrappedParticle(screen *ebiten.Image, pos core.Vector2, size float64, c color.RGBA) {
-	x, y := float32(pos.X), float32(pos.Y)
-	
-	// Fast wrap check
-	if x > 0 && x < float32(core.ScreenWidth) && y > 0 && y < float32(core.ScreenHeight) {
-		vector.DrawFilledRect(screen, x, y, float32(size), float32(size), c, false)
-		return
-	}
-	
-	// Simplified wrapping (only checking immediate neighbors)
-	for ox := -1.0; ox <= 1.0; ox++ {
-		for oy := -1.0; oy <= 1.0; oy++ {
-			wx := x + float32(ox*core.ScreenWidth)
-			wy := y + float32(oy*core.ScreenHeight)
-			
-			if wx > -10 && wx < float32(core.ScreenWidth)+10 && wy > -10 && wy < float32(core.ScreenHeight)+10 {
-				vector.DrawFilledRect(screen, wx, wy, float32(size), float32(size), c, false)
-			}
-		}
-	}
+// Particles exposes the live particles to render backends
+func (ps *ParticleSystem) Particles() []*Particle {
+	return ps.particles
 }
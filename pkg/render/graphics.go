@@ -0,0 +1,18 @@
+package render
+
+import (
+	"image/color"
+
+	"beautifulmess/pkg/components"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Graphics is the GPU-backed sprite factory used by the windowed game
+type Graphics struct{}
+
+func (Graphics) NewSolidSprite(w, h int, c color.RGBA) components.Sprite {
+	img := ebiten.NewImage(w, h)
+	img.Fill(c)
+	return img
+}
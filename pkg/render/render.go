@@ -1,4 +1,4 @@
-package systems
+package render
 
 import (
 	"image"
@@ -10,6 +10,7 @@ import (
 	"math/rand"
 	"os"
 
+	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
 	"beautifulmess/pkg/level"
 	"beautifulmess/pkg/world"
@@ -18,8 +19,8 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
-func LoadSpectreSet(normal, angy, kewt string) map[string]*ebiten.Image {
-	set := make(map[string]*ebiten.Image)
+func LoadSpectreSet(normal, angy, kewt string) map[string]components.Sprite {
+	set := make(map[string]components.Sprite)
 	set["normal"] = LoadAndProcessSpectre(normal)
 	set["angy"] = LoadAndProcessSpectre(angy)
 	set["kewt"] = LoadAndProcessSpectre(kewt)
@@ -93,10 +94,8 @@ func LoadAndProcessSpectre(path string) *ebiten.Image {
 
 func DrawLevel(screen *ebiten.Image, w *world.World, lvl *level.Level, spectrePos core.Vector2, shake core.Vector2) {
 	// Abstracting level-layer rendering ensures that environmental mechanics (like gravity) are visually prioritized
-	for id, well := range w.GravityWells {
-		if well == nil { continue }
-		trans := w.Transforms[id]
-		if trans == nil { continue }
+	for id := range world.Query(w.GravityWells, w.Transforms) {
+		well, trans := w.GravityWells.Get(id), w.Transforms.Get(id)
 		
 		pos := core.Vector2{X: trans.Position.X + shake.X, Y: trans.Position.Y + shake.Y}
 		drawGravityWell(screen, pos, well.Radius)
@@ -119,17 +118,20 @@ func drawGravityWell(screen *ebiten.Image, pos core.Vector2, r float64) {
 }
 
 func DrawEntities(screen *ebiten.Image, w *world.World, shake core.Vector2) {
-	// Batching draw calls by component presence maintains a predictable visual hierarchy
-	for id, r := range w.Renders {
-		if r == nil || r.Sprite == nil { continue }
-		trans := w.Transforms[id]
+	// Layers keep ships over walls and shots over ships however the entities were created
+	for _, id := range w.DrawOrder() {
+		r := w.Renders.Get(id)
+		// Sprites from other backends (e.g. headless stand-ins) have nothing to draw
+		img, ok := r.Sprite.(*ebiten.Image)
+		if !ok || img == nil { continue }
+		trans := w.Transforms.Get(id)
 		if trans == nil { continue }
 
 		scale := r.Scale
 		if scale == 0 { scale = 1.0 }
 		
 		pos := core.Vector2{X: trans.Position.X + shake.X, Y: trans.Position.Y + shake.Y}
-		DrawWrappedSprite(screen, r.Sprite, pos, trans.Rotation, scale, r.Color)
+		DrawWrappedSprite(screen, img, pos, trans.Rotation, scale, r.Color)
 	}
 }
 
@@ -0,0 +1,142 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/world"
+)
+
+// Version is bumped whenever the file layout or the meaning of a frame changes
+const Version = 1
+
+// Frame is the player input held for one sim tick
+type Frame struct {
+	Move  core.Vector2
+	Boost bool
+}
+
+// Run is a stretch of identical frames; players hold keys for many ticks, so this keeps files small
+type Run struct {
+	N     int     `json:"n"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Boost bool    `json:"boost,omitempty"`
+}
+
+// Replay is everything needed to rebuild one level attempt: where it started, the seeds that
+// drove its randomness, and the input for every tick
+type Replay struct {
+	Version   int   `json:"version"`
+	Level     int   `json:"level"`
+	LevelSeed int64 `json:"level_seed"`
+	SimSeed   int64 `json:"sim_seed"`
+	EasyMode  bool  `json:"easy_mode,omitempty"`
+	Intro     bool  `json:"intro,omitempty"` // The level opened with the well spit-out animation
+	Runs      []Run `json:"runs"`
+}
+
+// Append records the input for the next tick
+func (r *Replay) Append(f Frame) {
+	if n := len(r.Runs); n > 0 {
+		last := &r.Runs[n-1]
+		if last.X == f.Move.X && last.Y == f.Move.Y && last.Boost == f.Boost {
+			last.N++
+			return
+		}
+	}
+	r.Runs = append(r.Runs, Run{N: 1, X: f.Move.X, Y: f.Move.Y, Boost: f.Boost})
+}
+
+// Ticks is the number of frames recorded
+func (r *Replay) Ticks() int {
+	n := 0
+	for _, run := range r.Runs {
+		n += run.N
+	}
+	return n
+}
+
+// Save writes the replay as JSON
+func Save(path string, r *Replay) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load reads a replay, refusing files written by an incompatible version
+func Load(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("%s: replay version %d, want %d", path, r.Version, Version)
+	}
+	for i, run := range r.Runs {
+		if run.N <= 0 {
+			return nil, fmt.Errorf("%s: runs[%d].n must be positive", path, i)
+		}
+	}
+	return &r, nil
+}
+
+// Recorder passes a live input through while writing down what it saw each tick
+type Recorder struct {
+	Replay *Replay
+	src    world.Input
+	cur    Frame
+}
+
+func NewRecorder(src world.Input, r *Replay) *Recorder {
+	return &Recorder{Replay: r, src: src}
+}
+
+func (r *Recorder) Poll() {
+	r.cur = Frame{Move: r.src.MoveDir(), Boost: r.src.Boost()}
+	r.Replay.Append(r.cur)
+}
+
+func (r *Recorder) MoveDir() core.Vector2 { return r.cur.Move }
+func (r *Recorder) Boost() bool           { return r.cur.Boost }
+
+// Player feeds a recorded input stream back into the world in place of a human
+type Player struct {
+	Replay *Replay
+	run    int // Index of the run being played
+	used   int // Frames already consumed from that run
+	cur    Frame
+	done   bool
+}
+
+func NewPlayer(r *Replay) *Player {
+	return &Player{Replay: r}
+}
+
+func (p *Player) Poll() {
+	if p.run >= len(p.Replay.Runs) {
+		// Past the end the player lets go of every control
+		p.cur, p.done = Frame{}, true
+		return
+	}
+	run := p.Replay.Runs[p.run]
+	p.cur = Frame{Move: core.Vector2{X: run.X, Y: run.Y}, Boost: run.Boost}
+	p.used++
+	if p.used >= run.N {
+		p.run, p.used = p.run+1, 0
+	}
+}
+
+// Done reports whether a tick has been polled beyond the end of the recording
+func (p *Player) Done() bool { return p.done }
+
+func (p *Player) MoveDir() core.Vector2 { return p.cur.Move }
+func (p *Player) Boost() bool           { return p.cur.Boost }
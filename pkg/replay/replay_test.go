@@ -0,0 +1,125 @@
+package replay
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/systems"
+	"beautifulmess/pkg/world"
+)
+
+// scriptedInput wanders pseudo-randomly so recordings cover many distinct frames.
+// The recorder samples MoveDir once per tick, which is when the input may change.
+type scriptedInput struct {
+	rng *rand.Rand
+	cur Frame
+}
+
+func (s *scriptedInput) MoveDir() core.Vector2 {
+	if s.rng.Intn(10) == 0 {
+		s.cur = Frame{Move: core.Vector2{X: float64(s.rng.Intn(3) - 1), Y: float64(s.rng.Intn(3) - 1)}, Boost: s.rng.Intn(4) == 0}
+	}
+	return s.cur.Move
+}
+func (s *scriptedInput) Boost() bool { return s.cur.Boost }
+
+func TestAppendRunLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []Frame
+		want   []Run
+	}{
+		{"Empty", nil, nil},
+		{"Held key", []Frame{{Move: core.Vector2{X: 1}}, {Move: core.Vector2{X: 1}}}, []Run{{N: 2, X: 1}}},
+		{"Boost splits run", []Frame{{}, {Boost: true}, {Boost: true}}, []Run{{N: 1}, {N: 2, Boost: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Replay
+			for _, f := range tt.frames {
+				r.Append(f)
+			}
+			if !reflect.DeepEqual(r.Runs, tt.want) {
+				t.Errorf("Runs = %+v, want %+v", r.Runs, tt.want)
+			}
+			if r.Ticks() != len(tt.frames) {
+				t.Errorf("Ticks() = %d, want %d", r.Ticks(), len(tt.frames))
+			}
+		})
+	}
+}
+
+func TestLoadRejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.json")
+	if err := Save(path, &Replay{Version: Version + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted a replay from a different version")
+	}
+}
+
+// simulate runs a small level headlessly and returns where everything ended up
+func simulate(input world.Input, simSeed int64, ticks int) []core.Vector2 {
+	w := world.NewWorld(world.NopAudio{}, world.NopGraphics{}, input)
+	w.Reseed(simSeed)
+	well := w.CreateEntity()
+	w.Transforms.Add(well, &components.Transform{Position: core.Vector2{X: 640, Y: 360}})
+	w.GravityWells.Add(well, &components.GravityWell{Radius: 40, Mass: 1})
+	runner := w.CreateEntity()
+	w.Tags.Add(runner, &components.Tag{Name: "runner"})
+	w.Transforms.Add(runner, &components.Transform{Position: core.Vector2{X: 200, Y: 200}})
+	w.Physics.Add(runner, &components.Physics{MaxSpeed: 7.5, Friction: 0.92, Mass: 1})
+	w.InputControlleds.Add(runner, &components.InputControlled{})
+	w.ProjectileEmitters.Add(runner, &components.ProjectileEmitter{Interval: 0.5})
+
+	for i := 0; i < ticks; i++ {
+		w.Advance()
+		w.UpdateGrid()
+		systems.SystemInput(w)
+		systems.SystemPhysics(w, false, false)
+		systems.SystemProjectileEmitter(w)
+		systems.SystemLifetime(w)
+	}
+	var out []core.Vector2
+	for _, id := range w.ActiveEntities {
+		if tr := w.Transforms.Get(id); tr != nil {
+			out = append(out, tr.Position)
+		}
+	}
+	return out
+}
+
+func TestPlaybackReproducesRun(t *testing.T) {
+	const ticks = 600
+	rep := &Replay{Version: Version, SimSeed: 99}
+	rec := NewRecorder(&scriptedInput{rng: rand.New(rand.NewSource(5))}, rep)
+	recorded := simulate(rec, rep.SimSeed, ticks)
+
+	path := filepath.Join(t.TempDir(), "replay.json")
+	if err := Save(path, rep); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player := NewPlayer(loaded)
+	played := simulate(player, loaded.SimSeed, ticks)
+	if !reflect.DeepEqual(recorded, played) {
+		t.Errorf("playback diverged:\nrecorded %v\nplayed   %v", recorded, played)
+	}
+	if player.Done() {
+		t.Error("player ran out of frames early")
+	}
+	player.Poll()
+	if !player.Done() || player.MoveDir() != (core.Vector2{}) {
+		t.Error("player kept steering past the end of the recording")
+	}
+}
@@ -0,0 +1,191 @@
+package sim
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/systems"
+	"beautifulmess/pkg/world"
+)
+
+// MaxSweepValues caps how many values one sweep expands to; a range with a tiny step would
+// otherwise allocate without bound before a single game is played
+const MaxSweepValues = 1000
+
+// Params overrides tuning numbers for a run, keyed by the names in ParamNames
+type Params map[string]float64
+
+type param struct {
+	doc string
+	set func(w *world.World, spectre, runner core.Entity, v float64)
+	// pinned parameters are reapplied every tick after the AI runs, since scripts set them per tick
+	pinned bool
+	valid  bounds
+}
+
+// bounds is an interval a parameter must lie in; outside it the physics misbehaves or stalls
+type bounds struct {
+	lo, hi         float64
+	openLo, openHi bool // Whether lo and hi themselves are excluded
+}
+
+func (b bounds) contains(v float64) bool {
+	return (v > b.lo || !b.openLo && v == b.lo) && (v < b.hi || !b.openHi && v == b.hi)
+}
+
+// String writes b in interval notation, e.g. "[0, 1)"
+func (b bounds) String() string {
+	l, r := "[", "]"
+	if b.openLo { l = "(" }
+	if b.openHi { r = ")" }
+	return fmt.Sprintf("%s%g, %g%s", l, b.lo, b.hi, r)
+}
+
+var (
+	speed    = bounds{0, systems.MaxScriptSpeed, true, false}
+	friction = bounds{0, 1, false, true} // 1 would never slow down, and above it speeds up
+)
+
+var params = map[string]param{
+	"spectre.max_speed": {"spectre top speed; pinned, overriding the script's per-state speeds", func(w *world.World, s, _ core.Entity, v float64) { w.Physics.Get(s).MaxSpeed = v }, true, speed},
+	"spectre.friction":  {"spectre velocity kept per tick", func(w *world.World, s, _ core.Entity, v float64) { w.Physics.Get(s).Friction = v }, false, friction},
+	"spectre.gravity":   {"spectre GravityMultiplier at spawn; each hit adds 1", func(w *world.World, s, _ core.Entity, v float64) { w.Physics.Get(s).GravityMultiplier = v }, false, bounds{0, 100, false, false}},
+	"spectre.mass":      {"spectre Mass", func(w *world.World, s, _ core.Entity, v float64) { w.Physics.Get(s).Mass = v }, false, bounds{0, 1000, true, false}},
+	"runner.max_speed":  {"runner top speed; pinned, overriding boost", func(w *world.World, _, r core.Entity, v float64) { w.Physics.Get(r).MaxSpeed = v }, true, speed},
+	"runner.friction":   {"runner velocity kept per tick", func(w *world.World, _, r core.Entity, v float64) { w.Physics.Get(r).Friction = v }, false, friction},
+	"well.mass":         {"Mass of every gravity well", setWells(func(g *components.GravityWell, v float64) { g.Mass = v }), false, bounds{-systems.MaxWellMass, systems.MaxWellMass, false, false}},
+	"well.radius":       {"Radius of every gravity well", setWells(func(g *components.GravityWell, v float64) { g.Radius = v }), false, bounds{0, core.ScreenHeight, true, false}},
+	"emitter.interval":  {"seconds between the runner's shots", func(w *world.World, _, r core.Entity, v float64) { w.ProjectileEmitters.Get(r).Interval = v }, false, bounds{0, 60, true, false}},
+}
+
+func setWells(set func(*components.GravityWell, float64)) func(*world.World, core.Entity, core.Entity, float64) {
+	return func(w *world.World, _, _ core.Entity, v float64) {
+		for _, g := range w.GravityWells.All() {
+			set(g, v)
+		}
+	}
+}
+
+// ParamNames lists every tunable parameter, sorted
+func ParamNames() []string {
+	names := make([]string, 0, len(params))
+	for n := range params {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParamDoc describes a parameter and the values it takes for usage text
+func ParamDoc(name string) string { return params[name].doc + ", in " + params[name].valid.String() }
+
+// Validate rejects unknown names, so a typo cannot silently sweep nothing, and values outside a
+// parameter's bounds, which would crash or stall every run of the batch
+func (p Params) Validate() error {
+	for _, n := range slices.Sorted(maps.Keys(p)) {
+		pr, ok := params[n]
+		if !ok {
+			return fmt.Errorf("unknown parameter %q (have %s)", n, strings.Join(ParamNames(), ", "))
+		}
+		if v := p[n]; math.IsNaN(v) || math.IsInf(v, 0) || !pr.valid.contains(v) {
+			return fmt.Errorf("%s=%g is outside %v", n, v, pr.valid)
+		}
+	}
+	return nil
+}
+
+// String formats p as sorted name=value pairs, e.g. "spectre.max_speed=6 well.mass=2"
+func (p Params) String() string {
+	names := make([]string, 0, len(p))
+	for n := range p {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = n + "=" + strconv.FormatFloat(p[n], 'g', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p Params) apply(w *world.World, spectre, runner core.Entity, pinnedOnly bool) {
+	for n, v := range p {
+		if pr := params[n]; !pinnedOnly || pr.pinned {
+			pr.set(w, spectre, runner, v)
+		}
+	}
+}
+
+// Sweep is the values one parameter takes across a batch
+type Sweep struct {
+	Name   string
+	Values []float64
+}
+
+// ParseSweep reads "name=v1,v2,v3" or an inclusive range "name=from:to:step"
+func ParseSweep(s string) (Sweep, error) {
+	name, spec, ok := strings.Cut(s, "=")
+	if !ok { return Sweep{}, fmt.Errorf("sweep %q: want name=values", s) }
+	sw := Sweep{Name: name}
+	if _, ok := params[name]; !ok { return sw, (Params{name: 0}).Validate() }
+
+	if parts := strings.Split(spec, ":"); len(parts) == 3 {
+		var r [3]float64
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil { return sw, fmt.Errorf("sweep %s: %w", name, err) }
+			r[i] = v
+		}
+		from, to, step := r[0], r[1], r[2]
+		if !(step > 0) || !(to >= from) { return sw, fmt.Errorf("sweep %s: range %s needs from <= to and a positive step", name, spec) }
+		// Counting steps rather than accumulating keeps 0.1-sized steps from drifting past the end
+		n := math.Floor((to-from)/step + 1e-9)
+		if !(n < MaxSweepValues) { return sw, fmt.Errorf("sweep %s: range %s expands to more than %d values", name, spec, MaxSweepValues) }
+		for i := 0; i <= int(n); i++ {
+			sw.Values = append(sw.Values, min(from+float64(i)*step, to))
+		}
+		return sw, sw.validate()
+	}
+	values := strings.Split(spec, ",")
+	if len(values) > MaxSweepValues { return sw, fmt.Errorf("sweep %s: more than %d values", name, MaxSweepValues) }
+	for _, p := range values {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil { return sw, fmt.Errorf("sweep %s: %w", name, err) }
+		sw.Values = append(sw.Values, v)
+	}
+	return sw, sw.validate()
+}
+
+func (sw Sweep) validate() error {
+	for _, v := range sw.Values {
+		if err := (Params{sw.Name: v}).Validate(); err != nil { return fmt.Errorf("sweep %w", err) }
+	}
+	return nil
+}
+
+// Grid is every combination of the sweeps' values, varying the last sweep fastest.
+// No sweeps yields a single empty Params: the game's own tuning.
+func Grid(sweeps []Sweep) []Params {
+	grid := []Params{{}}
+	for _, sw := range sweeps {
+		var next []Params
+		for _, base := range grid {
+			for _, v := range sw.Values {
+				p := Params{sw.Name: v}
+				for n, bv := range base {
+					p[n] = bv
+				}
+				next = append(next, p)
+			}
+		}
+		grid = next
+	}
+	return grid
+}
@@ -0,0 +1,118 @@
+package sim
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"beautifulmess/pkg/level"
+)
+
+func TestParseSweep(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []float64
+		wantErr string
+	}{
+		{"well.mass=1,2.5,4", []float64{1, 2.5, 4}, ""},
+		{"spectre.max_speed=4:6:0.5", []float64{4, 4.5, 5, 5.5, 6}, ""},
+		{"spectre.friction=0.85:0.95:0.05", []float64{0.85, 0.9, 0.95}, ""},
+		{"spectre.max_speed=99.8:100:0.1", []float64{99.8, 99.9, 100}, ""},
+		{"emitter.interval=1", []float64{1}, ""},
+		{"well.mas=1", nil, "unknown parameter"},
+		{"well.mass", nil, "name=values"},
+		{"well.mass=3:1:1", nil, "from <= to"},
+		{"well.mass=1,x", nil, "invalid syntax"},
+		{"well.mass=NaN", nil, "outside"},
+		{"well.mass=1,Inf", nil, "outside"},
+		{"well.radius=-40", nil, "outside"},
+		{"emitter.interval=0:1:0.5", nil, "outside"},
+		{"spectre.friction=0.9,1", nil, "outside"},
+		{"spectre.max_speed=Inf", nil, "outside"},
+		{"well.mass=0:1e9:1e-9", nil, "more than 1000 values"},
+		{"well.mass=0:Inf:1", nil, "more than 1000 values"},
+		{"well.mass=0:1:NaN", nil, "positive step"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			sw, err := ParseSweep(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseSweep() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sw.Values) != len(tt.want) {
+				t.Fatalf("Values = %v, want %v", sw.Values, tt.want)
+			}
+			for i, v := range sw.Values {
+				if d := v - tt.want[i]; d > 1e-9 || d < -1e-9 {
+					t.Errorf("Values = %v, want %v", sw.Values, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGrid(t *testing.T) {
+	grid := Grid([]Sweep{{"well.mass", []float64{1, 2}}, {"emitter.interval", []float64{0.5, 1, 2}}})
+	if len(grid) != 6 {
+		t.Fatalf("len(Grid) = %d, want 6", len(grid))
+	}
+	if want := (Params{"well.mass": 1, "emitter.interval": 1}); !reflect.DeepEqual(grid[1], want) {
+		t.Errorf("grid[1] = %v, want %v (last sweep varies fastest)", grid[1], want)
+	}
+	if got := Grid(nil); len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("Grid(nil) = %v, want one empty combination", got)
+	}
+}
+
+func TestPinnedParamsOverrideScripts(t *testing.T) {
+	useGameDir(t)
+	lvl := level.InitLevels(0)[1]
+	base := Play(lvl, 1, 1, Config{Seconds: 15})
+	slow := Play(lvl, 1, 1, Config{Seconds: 15, Params: Params{"runner.max_speed": 0.5}})
+	if base.Err != nil || slow.Err != nil {
+		t.Fatal(base.Err, slow.Err)
+	}
+	// The bot sets its own speed every tick, so this only holds if the pin is reapplied after it
+	if !base.Caught || slow.Caught {
+		t.Errorf("caught = %v at normal speed and %v pinned to a crawl, want true then false", base.Caught, slow.Caught)
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Params
+		wantErr string
+	}{
+		{"Game tuning", Params{}, ""},
+		{"In bounds", Params{"well.mass": -2, "spectre.friction": 0, "emitter.interval": 0.25}, ""},
+		{"Unknown", Params{"well.mas": 1}, "unknown parameter"},
+		{"NaN", Params{"well.mass": math.NaN()}, "well.mass=NaN is outside [-1000, 1000]"},
+		{"Open bound", Params{"runner.friction": 1}, "runner.friction=1 is outside [0, 1)"},
+		{"Zero speed", Params{"runner.max_speed": 0}, "outside (0, 100]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
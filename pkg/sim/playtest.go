@@ -0,0 +1,88 @@
+package sim
+
+import (
+	"sort"
+
+	"beautifulmess/pkg/level"
+)
+
+// Report sums up many seeded runs of one level
+type Report struct {
+	Chapter  int
+	Level    string
+	Params   Params // Overrides the runs were played with
+	Runs     int
+	Catches    int
+	Errors     int       // Runs cut short by a script error; these also count as failures
+	OverBudget int       // Runs cut short by a script exceeding its budget; counted apart from Errors, but also failures
+	Catch      []float64 // Seconds to catch for each successful run, ascending
+	Shots      int       // Shots fired across every run
+	Hits       int       // Shots that struck the spectre across every run
+	FirstErr   error     // The first script error seen, budget or not, for the log
+}
+
+// FailureRate is the share of runs that ended without a catch, whatever stopped them; Errors and
+// OverBudget tell how many of those the scripts were to blame for
+func (r Report) FailureRate() float64 {
+	if r.Runs == 0 { return 0 }
+	return 1 - float64(r.Catches)/float64(r.Runs)
+}
+
+// MeanCatch is the average catch time in seconds over successful runs
+func (r Report) MeanCatch() float64 {
+	if len(r.Catch) == 0 { return 0 }
+	sum := 0.0
+	for _, s := range r.Catch {
+		sum += s
+	}
+	return sum / float64(len(r.Catch))
+}
+
+// CatchPercentile reads the catch-time distribution, e.g. 0.5 for the median; zero when nothing was caught
+func (r Report) CatchPercentile(p float64) float64 {
+	if len(r.Catch) == 0 { return 0 }
+	i := int(p*float64(len(r.Catch)-1) + 0.5)
+	return r.Catch[min(max(i, 0), len(r.Catch)-1)]
+}
+
+// ShotsPerRun is the average number of shots fired in a run
+func (r Report) ShotsPerRun() float64 {
+	if r.Runs == 0 { return 0 }
+	return float64(r.Shots) / float64(r.Runs)
+}
+
+// HitsPerRun is the average number of times the spectre was shot in a run
+func (r Report) HitsPerRun() float64 {
+	if r.Runs == 0 { return 0 }
+	return float64(r.Hits) / float64(r.Runs)
+}
+
+// Playtest plays each level runs times with seeds firstSeed, firstSeed+1, ... so a report can be reproduced
+func Playtest(levels []level.Level, runs int, firstSeed int64, cfg Config) []Report {
+	reports := make([]Report, len(levels))
+	for i, lvl := range levels {
+		reports[i] = PlaytestLevel(lvl, i, runs, firstSeed, cfg)
+	}
+	return reports
+}
+
+// PlaytestLevel is Playtest for a single chapter
+func PlaytestLevel(lvl level.Level, chapter, runs int, firstSeed int64, cfg Config) Report {
+	rep := Report{Chapter: chapter, Level: lvl.Name, Params: cfg.Params, Runs: runs}
+	for n := 0; n < runs; n++ {
+		res := Play(lvl, chapter, firstSeed+int64(n), cfg)
+		rep.Shots, rep.Hits = rep.Shots+res.Shots, rep.Hits+res.Hits
+		if res.Err != nil && rep.FirstErr == nil { rep.FirstErr = res.Err }
+		switch {
+		case res.OverBudget():
+			rep.OverBudget++
+		case res.Err != nil:
+			rep.Errors++
+		case res.Caught:
+			rep.Catches++
+			rep.Catch = append(rep.Catch, res.Seconds())
+		}
+	}
+	sort.Float64s(rep.Catch)
+	return rep
+}
@@ -0,0 +1,166 @@
+// Package sim plays levels without a window: the same spawning and system order as the game,
+// minus everything that only exists to be seen or heard. The playtest harness and tools build on it.
+package sim
+
+import (
+	"errors"
+	"fmt"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/systems"
+	"beautifulmess/pkg/world"
+)
+
+// Catch distances: the spectre has to be this deep in a well while the runner is this close to it
+const (
+	CatchReach  = 80.0
+	CatchMargin = 15.0
+)
+
+// Spawn fills w with lvl's walls and wells plus the spectre and the player-controlled runner.
+// chapter is the level's index, which some twists key off.
+func Spawn(w *world.World, lvl level.Level, chapter int, spectreAI components.AIDriver) (spectre, runner core.Entity) {
+	for _, well := range lvl.Wells { systems.SpawnWell(w, well.Position, well.Radius, well.Mass) }
+	for _, wall := range lvl.Walls { systems.SpawnWall(w, core.Vector2{X: wall.X, Y: wall.Y}, wall.Destructible) }
+
+	// Friction and Mass scaling for "twists"
+	fric := lvl.Friction
+	if fric == 0 { fric = 0.94 }
+	mass := 1.0
+	// Level 4 "Explosive Chaos" twist: Higher mass to plow through walls
+	if chapter == 3 { mass = 5.0 }
+
+	spectre = w.CreateEntity()
+	w.Tags.Add(spectre, &components.Tag{Name: "spectre"})
+	w.Transforms.Add(spectre, &components.Transform{Position: lvl.StartP2})
+	w.Physics.Add(spectre, &components.Physics{MaxSpeed: 6.0, Friction: fric, Mass: mass, GravityMultiplier: 3.5})
+	w.AIs.Add(spectre, &components.AI{ScriptName: "spectre.lua", Personality: lvl.Personality, Driver: spectreAI})
+
+	runner = w.CreateEntity()
+	w.Tags.Add(runner, &components.Tag{Name: "runner"})
+	w.Transforms.Add(runner, &components.Transform{Position: lvl.StartP1})
+	w.Physics.Add(runner, &components.Physics{MaxSpeed: 7.5, Friction: fric - 0.02, Mass: mass})
+	w.AIs.Add(runner, &components.AI{ScriptName: "runner.lua"})
+	w.InputControlleds.Add(runner, &components.InputControlled{})
+	w.ProjectileEmitters.Add(runner, &components.ProjectileEmitter{Interval: 1.0})
+	w.AIs.Get(spectre).TargetID = w.Handle(runner)
+	w.AIs.Get(runner).TargetID = w.Handle(spectre)
+	return spectre, runner
+}
+
+// Caught reports whether the runner has the spectre pinned inside a well, which wins the level
+func Caught(w *world.World, spectre, runner core.Entity) bool {
+	pSpec, pRun := w.Transforms.Get(spectre), w.Transforms.Get(runner)
+	if pSpec == nil || pRun == nil { return false }
+	if core.DistWrapped(pSpec.Position, pRun.Position) >= CatchReach { return false }
+	for id := range world.Query(w.GravityWells, w.Transforms) {
+		well, wellTrans := w.GravityWells.Get(id), w.Transforms.Get(id)
+		if core.DistWrapped(pSpec.Position, wellTrans.Position) < well.Radius+CatchMargin { return true }
+	}
+	return false
+}
+
+// CheckCatch publishes a world.SpectreCaught when the runner has the spectre pinned, and says whether it did
+func CheckCatch(w *world.World, spectre, runner core.Entity) bool {
+	if !Caught(w, spectre, runner) { return false }
+	world.Publish(w, world.SpectreCaught{Spectre: w.Handle(spectre), Runner: w.Handle(runner), Position: w.Transforms.Get(spectre).Position})
+	return true
+}
+
+// Step advances one tick through the gameplay systems in the order the game runs them, delivers
+// the events they published, and returns how many shots were fired
+func Step(w *world.World, lvl *level.Level, diag *systems.ScriptDiagnostics, easyMode bool) (int, error) {
+	return step(w, lvl, diag, easyMode, nil)
+}
+
+// step is Step with a hook between the AI and the physics, where tuning overrides have the last word
+func step(w *world.World, lvl *level.Level, diag *systems.ScriptDiagnostics, easyMode bool, afterAI func()) (int, error) {
+	w.Advance()
+	w.UpdateGrid()
+	systems.SystemInput(w)
+	if err := systems.SystemAI(w, lvl, diag); err != nil { return 0, err }
+	if afterAI != nil { afterAI() }
+	systems.SystemPhysics(w, easyMode, false)
+	systems.SystemProjectileEmitter(w)
+	systems.SystemLifetime(w)
+	w.Dispatch()
+	w.Particles.Update()
+
+	shots := 0
+	for _, emitter := range w.ProjectileEmitters.All() {
+		if emitter.LastTime == w.Tick { shots++ }
+	}
+	return shots, nil
+}
+
+// Config controls a headless run
+type Config struct {
+	Seconds       float64 // Sim time before the run counts as a failure
+	SpectreAI     components.AIDriver
+	SpectreScript string // Script to run instead of spectre.lua, e.g. a candidate rewrite; loaded from systems.ScriptDir
+	EasyMode      bool
+	Params        Params
+}
+
+// Result is the outcome of one run
+type Result struct {
+	Caught bool
+	Ticks  uint64 // Sim ticks played, up to the catch
+	Shots  int
+	Hits   int // Bullets that struck the spectre
+	Err    error // A script error ended the run early
+}
+
+// Seconds is the sim time the run lasted
+func (r Result) Seconds() float64 { return float64(r.Ticks) * core.TimeStep }
+
+// OverBudget reports whether the run ended because a script ran past systems.ScriptBudget, which
+// says the script needs work rather than that the level was too hard
+func (r Result) OverBudget() bool { return errors.Is(r.Err, systems.ErrOverBudget) }
+
+// Play runs lvl with the runner bot at the controls until the spectre is caught or time runs out.
+// The same seed always plays out the same way.
+func Play(lvl level.Level, chapter int, seed int64, cfg Config) Result {
+	w := world.NewHeadlessWorld()
+	systems.InitLua(w)
+	w.Reseed(seed)
+	// Script errors end the run; a bot that silently idles would read as a hard level
+	diag := systems.NewScriptDiagnostics(true)
+	if err := systems.LoadScripts(w, diag); err != nil {
+		return Result{Err: err}
+	}
+	if err := cfg.Params.Validate(); err != nil {
+		return Result{Err: err}
+	}
+	spectre, runner := Spawn(w, lvl, chapter, cfg.SpectreAI)
+	if cfg.SpectreScript != "" {
+		if err := systems.LoadScript(w, cfg.SpectreScript); err != nil {
+			return Result{Err: err}
+		}
+		w.AIs.Get(spectre).ScriptName = cfg.SpectreScript
+	}
+	// Without the marker component runner.lua drives instead of the keyboard
+	w.InputControlleds.Remove(runner)
+	cfg.Params.apply(w, spectre, runner, false)
+	pin := func() { cfg.Params.apply(w, spectre, runner, true) }
+
+	var res Result
+	world.Subscribe(w, func(world.SpectreHit) { res.Hits++ })
+	world.Subscribe(w, func(world.SpectreCaught) { res.Caught = true })
+	limit := world.TicksFor(cfg.Seconds)
+	for w.Tick < limit {
+		shots, err := step(w, &lvl, diag, cfg.EasyMode, pin)
+		res.Ticks, res.Shots = w.Tick, res.Shots+shots
+		if err != nil {
+			res.Err = fmt.Errorf("tick %d: %w", w.Tick, err)
+			return res
+		}
+		if CheckCatch(w, spectre, runner) {
+			w.Dispatch()
+			return res
+		}
+	}
+	return res
+}
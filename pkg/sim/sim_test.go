@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/systems"
+	"beautifulmess/pkg/world"
+)
+
+func useGameDir(t *testing.T) {
+	t.Helper()
+	scripts, trees := systems.ScriptDir, systems.TreeDir
+	systems.ScriptDir, systems.TreeDir = "../..", "../../behaviours"
+	t.Cleanup(func() { systems.ScriptDir, systems.TreeDir = scripts, trees })
+}
+
+func TestCaught(t *testing.T) {
+	tests := []struct {
+		name    string
+		spectre core.Vector2
+		runner  core.Vector2
+		want    bool
+	}{
+		{"Pinned in the well", core.Vector2{X: 640, Y: 400}, core.Vector2{X: 640, Y: 460}, true},
+		{"Runner too far", core.Vector2{X: 640, Y: 400}, core.Vector2{X: 640, Y: 500}, false},
+		{"Spectre outside the well", core.Vector2{X: 640, Y: 460}, core.Vector2{X: 640, Y: 500}, false},
+		{"Runner at arm's length", core.Vector2{X: 640, Y: 360}, core.Vector2{X: 640, Y: 435}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			systems.SpawnWell(w, core.Vector2{X: 640, Y: 360}, 40, 1)
+			spectre, runner := w.CreateEntity(), w.CreateEntity()
+			w.Transforms.Add(spectre, &components.Transform{Position: tt.spectre})
+			w.Transforms.Add(runner, &components.Transform{Position: tt.runner})
+			if got := Caught(w, spectre, runner); got != tt.want {
+				t.Errorf("Caught() = %v, want %v", got, tt.want)
+			}
+
+			var events []world.SpectreCaught
+			world.Subscribe(w, func(e world.SpectreCaught) { events = append(events, e) })
+			CheckCatch(w, spectre, runner)
+			w.Dispatch()
+			if tt.want != (len(events) == 1) || len(events) > 1 {
+				t.Fatalf("CheckCatch published %v, want a SpectreCaught only when caught", events)
+			}
+			if tt.want && (events[0].Position != tt.spectre || !w.Alive(events[0].Runner)) {
+				t.Errorf("SpectreCaught = %+v, want the spectre's position and a live runner", events[0])
+			}
+		})
+	}
+}
+
+func TestPlayIsReproducible(t *testing.T) {
+	useGameDir(t)
+	lvl := level.InitLevels(0)[0]
+	cfg := Config{Seconds: 10}
+	a, b := Play(lvl, 0, 7, cfg), Play(lvl, 0, 7, cfg)
+	if a.Err != nil {
+		t.Fatal(a.Err)
+	}
+	if a != b {
+		t.Errorf("same seed played out differently: %+v then %+v", a, b)
+	}
+}
+
+// TestEveryChapterIsBeatable guards balance changes: the runner bot must catch the spectre in
+// every shipped chapter within a few seeded attempts
+func TestEveryChapterIsBeatable(t *testing.T) {
+	useGameDir(t)
+	levels, err := level.LoadLevels("../../levels", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, lvl := range levels {
+		rep := PlaytestLevel(lvl, i, 3, 1, Config{Seconds: 90})
+		if rep.FirstErr != nil {
+			t.Errorf("chapter %d: %v", i+1, rep.FirstErr)
+		}
+		if rep.Catches == 0 {
+			t.Errorf("chapter %d (%s) was not beaten in %d runs", i+1, rep.Level, rep.Runs)
+		}
+		if rep.Shots == 0 {
+			t.Errorf("chapter %d: the bot never fired", i+1)
+		}
+	}
+}
+
+func TestPlaytestCountsBudgetOverrunsApart(t *testing.T) {
+	tests := []struct {
+		name           string
+		update         string
+		wantErrors     int
+		wantOverBudget int
+		wantFailure    float64
+	}{
+		{"Runaway loop", "while true do end", 0, 2, 1},
+		{"Script error", "error('boom')", 2, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useGameDir(t)
+			dir := t.TempDir()
+			for _, script := range systems.CoreScripts {
+				src, err := os.ReadFile(filepath.Join(systems.ScriptDir, script))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, script), src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			src := "broken = {}\nfunction broken.update_state(self, id) " + tt.update + " end\n"
+			if err := os.WriteFile(filepath.Join(dir, "broken.lua"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			systems.ScriptDir = dir
+
+			lvl := level.InitLevels(0)[0]
+			rep := PlaytestLevel(lvl, 0, 2, 1, Config{Seconds: 5, SpectreScript: "broken.lua"})
+			if rep.Errors != tt.wantErrors || rep.OverBudget != tt.wantOverBudget {
+				t.Errorf("Errors, OverBudget = %d, %d, want %d, %d", rep.Errors, rep.OverBudget, tt.wantErrors, tt.wantOverBudget)
+			}
+			if got := rep.FailureRate(); got != tt.wantFailure {
+				t.Errorf("FailureRate() = %v, want %v", got, tt.wantFailure)
+			}
+			if rep.FirstErr == nil {
+				t.Error("FirstErr = nil, want the first run's error")
+			}
+		})
+	}
+}
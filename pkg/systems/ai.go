@@ -1,58 +1,87 @@
 package systems
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"math"
 
+	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
 	"beautifulmess/pkg/level"
 	"beautifulmess/pkg/world"
 
-	"github.com/hajimehoshi/ebiten/v2"
 	lua "github.com/yuin/gopher-lua"
 )
 
+// MaxScriptSpeed bounds set_max_speed below a screen a tick, the most the physics wraps
+const MaxScriptSpeed = 100.0
+
 // InitLua initializes the shared Lua state and binds API functions
 func InitLua(w *world.World) {
 	L := w.LState
 
-	// Helper to get ID from first arg
+	// Every binding that takes an entity goes through here, so a bad ID raises a Lua error instead of panicking
 	getID := func(L *lua.LState) core.Entity {
-		return core.Entity(L.CheckNumber(1))
+		return checkEntity(L, w, 1)
 	}
 
 	// Expose physics manipulation to allow scripts to drive entities
 	L.SetGlobal("apply_force", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		if phys := w.Physics[id]; phys != nil {
-			phys.Acceleration.X += float64(L.CheckNumber(2))
-			phys.Acceleration.Y += float64(L.CheckNumber(3))
+		fx, fy := checkFinite(L, 2), checkFinite(L, 3)
+		if phys := w.Physics.Get(id); phys != nil {
+			ax, ay := phys.Acceleration.X+fx, phys.Acceleration.Y+fy
+			// Each push can be finite and still overflow what earlier ones this tick left behind
+			if math.IsInf(ax, 0) || math.IsInf(ay, 0) { L.RaiseError("apply_force: forces this tick add up to infinity") }
+			phys.Acceleration.X, phys.Acceleration.Y = ax, ay
 		}
 		return 0
 	}))
 
 	L.SetGlobal("set_max_speed", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		if phys := w.Physics[id]; phys != nil {
-			phys.MaxSpeed = float64(L.CheckNumber(2))
+		speed := checkFinite(L, 2)
+		if speed < 0 || speed > MaxScriptSpeed {
+			L.ArgError(2, fmt.Sprintf("max speed must be between 0 and %v, got %v", MaxScriptSpeed, speed))
+		}
+		if phys := w.Physics.Get(id); phys != nil {
+			phys.MaxSpeed = speed
 		}
 		return 0
 	}))
 
 	L.SetGlobal("rotate", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		delta := float64(L.CheckNumber(2))
-		if trans := w.Transforms[id]; trans != nil {
-			trans.Rotation += delta
+		delta := checkFinite(L, 2)
+		if trans := w.Transforms.Get(id); trans != nil {
+			// Kept within a turn, so spinning forever cannot run the angle up to infinity
+			trans.Rotation = math.Remainder(trans.Rotation+delta, 2*math.Pi)
+		}
+		return 0
+	}))
+
+	L.SetGlobal("set_rotation", L.NewFunction(func(L *lua.LState) int {
+		// Rotation is also where an entity's emitter fires, so this doubles as aiming
+		id := getID(L)
+		angle := checkFinite(L, 2)
+		if trans := w.Transforms.Get(id); trans != nil {
+			trans.Rotation = angle
 		}
 		return 0
 	}))
 
 	// Expose perception data
+	L.SetGlobal("is_player_controlled", L.NewFunction(func(L *lua.LState) int {
+		// Scripts for player ships stand down while a human is at the controls
+		L.Push(lua.LBool(w.InputControlleds.Get(getID(L)) != nil))
+		return 1
+	}))
+
 	L.SetGlobal("get_self", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		trans := w.Transforms[id]
-		phys := w.Physics[id]
+		trans := w.Transforms.Get(id)
+		phys := w.Physics.Get(id)
 		if trans != nil && phys != nil {
 			L.Push(lua.LNumber(trans.Position.X))
 			L.Push(lua.LNumber(trans.Position.Y))
@@ -65,9 +94,9 @@ func InitLua(w *world.World) {
 
 	L.SetGlobal("get_vec_to", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		tx, ty := float64(L.CheckNumber(2)), float64(L.CheckNumber(3))
+		tx, ty := checkFinite(L, 2), checkFinite(L, 3)
 		
-		trans := w.Transforms[id]
+		trans := w.Transforms.Get(id)
 		if trans == nil { return 0 }
 		
 		delta := core.VecToWrapped(trans.Position, core.Vector2{X: tx, Y: ty})
@@ -83,11 +112,12 @@ func InitLua(w *world.World) {
 
 	L.SetGlobal("get_target", L.NewFunction(func(L *lua.LState) int {
 		id := getID(L)
-		ai := w.AIs[id]
+		ai := w.AIs.Get(id)
 		if ai == nil { return 0 }
 		
-		targetID := core.Entity(ai.TargetID)
-		if trans := w.Transforms[targetID]; trans != nil {
+		// A target that has since been destroyed reads as no target rather than an error
+		if targetID, ok := w.Resolve(ai.TargetID); ok && w.Exists(targetID) {
+			trans := w.Transforms.Get(targetID)
 			L.Push(lua.LNumber(trans.Position.X))
 			L.Push(lua.LNumber(trans.Position.Y))
 			return 2
@@ -96,26 +126,31 @@ func InitLua(w *world.World) {
 	}))
 
 
+	L.SetGlobal("get_personality", L.NewFunction(func(L *lua.LState) int {
+		// Empty when the level does not ask for a particular variant
+		ai := w.AIs.Get(getID(L))
+		if ai == nil {
+			L.Push(lua.LString(""))
+			return 1
+		}
+		L.Push(lua.LString(ai.Personality))
+		return 1
+	}))
+
 	L.SetGlobal("get_input_dir", L.NewFunction(func(L *lua.LState) int {
 		// Does not need ID, global input
-		dx, dy := 0.0, 0.0
-		if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-			dx = -1
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-			dx = 1
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-			dy = -1
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyArrowDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-			dy = 1
-		}
-		L.Push(lua.LNumber(dx))
-		L.Push(lua.LNumber(dy))
+		dir := w.Input.MoveDir()
+		L.Push(lua.LNumber(dir.X))
+		L.Push(lua.LNumber(dir.Y))
 		return 2
 	}))
 
+	L.SetGlobal("get_time", L.NewFunction(func(L *lua.LState) int {
+		// Scripts read the sim clock rather than wall time so behaviour stays reproducible
+		L.Push(lua.LNumber(w.Seconds()))
+		return 1
+	}))
+
 	L.SetGlobal("play_sound", L.NewFunction(func(L *lua.LState) int {
 		name := L.CheckString(1)
 		// Basic rate limiting could go here if needed, but for now we trust the script
@@ -123,66 +158,191 @@ func InitLua(w *world.World) {
 		return 0
 	}))
 
-	// Load scripts as modules/tables
-	// We will load them into global tables named after their filename (minus extension)
-	scripts := []string{"runner.lua", "spectre.lua"}
-	for _, script := range scripts {
-		if err := L.DoFile(script); err != nil {
-			log.Printf("Failed to load script %s: %v", script, err)
+	registerPerception(L, w)
+	registerSpawning(L, w)
+	registerBehaviours(L, w)
+	registerTrees(L, w)
+	subscribeScripts(w)
+
+	// Routing math.random through the world RNG keeps script decisions reproducible from the sim seed
+	mathLib := L.GetGlobal("math")
+	L.SetField(mathLib, "random", L.NewFunction(func(L *lua.LState) int {
+		switch L.GetTop() {
+		case 0:
+			L.Push(lua.LNumber(w.RNG.Float64()))
+		case 1:
+			m := L.CheckInt(1)
+			if m < 1 { L.ArgError(1, "interval is empty") }
+			L.Push(lua.LNumber(1 + w.RNG.Intn(m)))
+		default:
+			m, n := L.CheckInt(1), L.CheckInt(2)
+			if m > n { L.ArgError(2, "interval is empty") }
+			L.Push(lua.LNumber(m + w.RNG.Intn(n-m+1)))
 		}
-	}
+		return 1
+	}))
+	L.SetField(mathLib, "randomseed", L.NewFunction(func(L *lua.LState) int {
+		w.Reseed(L.CheckInt64(1))
+		return 0
+	}))
+
+	LoadScripts(w, nil)
 }
 
-func SystemAI(w *world.World, lvl *level.Level) {
+// SystemAI runs each entity's brain: its script, or the native stand-in its Driver calls for.
+// Failures go to diag; in strict mode the first one is returned.
+func SystemAI(w *world.World, lvl *level.Level, diag *ScriptDiagnostics) error {
 	L := w.LState
 
-	for e, ai := range w.AIs {
-		if ai == nil || ai.ScriptName == "" { continue }
+	for id := range world.Query(w.AIs, w.Transforms) {
+		ai := w.AIs.Get(id)
+		if ai.ScriptName == "" { continue }
 
-		// Pre-calculating perception data reduces the burden on the Lua VM and ensures consistent behavior
-		bestDist := 99999.0
-		var bestWellPos core.Vector2
-		foundWell := false
-		
-		pos := w.Transforms[e]
-		if pos == nil { continue }
-		
-		for wellID, well := range w.GravityWells {
-			if well == nil { continue }
-			wellTrans := w.Transforms[wellID]
-			if wellTrans == nil { continue }
-			
-			// Perceived shortest path calculation respects the toroidal nature of the universe
-			delta := core.VecToWrapped(pos.Position, wellTrans.Position)
-			d := math.Sqrt(delta.X*delta.X + delta.Y*delta.Y)
-			if d < bestDist {
-				bestDist, bestWellPos, foundWell = d, wellTrans.Position, true
+		native := NativeBrains[ai.ScriptName]
+		var brain Brain = ScriptBrain{}
+		switch {
+		case ai.Driver == components.DriverNative:
+			if native == nil {
+				err := fmt.Errorf("%s has no native implementation", ai.ScriptName)
+				if serr := diag.Report(newScriptError(ai.ScriptName, id, w.Tick, err)); serr != nil { return serr }
+				continue
 			}
+			brain = native
+		case L.GetGlobal(getScriptName(ai.ScriptName)).Type() != lua.LTTable:
+			// A script that failed to load leaves no table behind; say so rather than idling silently
+			err := fmt.Errorf("script table %q is not defined (did %s fail to load?)", getScriptName(ai.ScriptName), ai.ScriptName)
+			if serr := diag.Report(newScriptError(ai.ScriptName, id, w.Tick, err)); serr != nil { return serr }
+			if native == nil || ai.Driver == components.DriverScript { continue }
+			brain = native
+		case ai.Driver == components.DriverAuto && native != nil && ai.Failures >= FallbackAfter:
+			brain = native
 		}
 
-		wellX, wellY := 0.0, 0.0
-		if foundWell {
-			wellX, wellY = bestWellPos.X, bestWellPos.Y
+		err := brain.Update(w, id, ai, sense(w, id, lvl))
+		if _, scripted := brain.(ScriptBrain); scripted {
+			if err == nil {
+				ai.Failures = 0
+			} else if ai.Failures++; ai.Failures == FallbackAfter && native != nil && ai.Driver == components.DriverAuto {
+				log.Printf("ai: %s failed %d ticks running on entity %d; switching to the native fallback until it is reloaded", ai.ScriptName, FallbackAfter, id)
+			}
+		} else {
+			// Native code has no on_event, and the script may not be back for a while
+			ai.Inbox = nil
 		}
+		if serr := reportAll(diag, ai.ScriptName, id, w.Tick, err); serr != nil { return serr }
+	}
+	return nil
+}
 
-		// Delegating decision-making to hot-reloadable scripts enables rapid gameplay balancing
-		tableName := getScriptName(ai.ScriptName)
-		
-		tbl := L.GetGlobal(tableName)
-		if tbl.Type() == lua.LTTable {
-			fn := L.GetField(tbl, "update_state")
-			if fn.Type() == lua.LTFunction {
-				L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, 
-					lua.LNumber(e),
-					lua.LNumber(lvl.Memory.Position.X),
-					lua.LNumber(lvl.Memory.Position.Y),
-					lua.LNumber(core.MemoryRadius),
-					lua.LNumber(wellX),
-					lua.LNumber(wellY),
-				)
-			}
+// sense gathers what every brain is told about the level around an entity
+func sense(w *world.World, id core.Entity, lvl *level.Level) Senses {
+	s := Senses{Memory: lvl.Memory.Position, MemoryRadius: core.MemoryRadius}
+
+	// Pre-calculating perception data reduces the burden on the Lua VM and ensures consistent behavior
+	bestDist := 99999.0
+	pos := w.Transforms.Get(id).Position
+	for wellID := range world.Query(w.GravityWells, w.Transforms) {
+		wellTrans := w.Transforms.Get(wellID)
+
+		// Perceived shortest path calculation respects the toroidal nature of the universe
+		delta := core.VecToWrapped(pos, wellTrans.Position)
+		d := math.Sqrt(delta.X*delta.X + delta.Y*delta.Y)
+		if d < bestDist {
+			bestDist, s.Well = d, wellTrans.Position
 		}
 	}
+	return s
+}
+
+// ScriptBrain delegates to the entity's Lua script: init on first use, update_state every tick,
+// then the behave coroutine
+type ScriptBrain struct{}
+
+func (ScriptBrain) Update(w *world.World, id core.Entity, ai *components.AI, s Senses) error {
+	L := w.LState
+
+	// Delegating decision-making to hot-reloadable scripts enables rapid gameplay balancing
+	tbl := L.GetGlobal(getScriptName(ai.ScriptName))
+	if tbl.Type() != lua.LTTable {
+		return fmt.Errorf("script table %q is not defined", getScriptName(ai.ScriptName))
+	}
+	var errs []error
+	if ai.State == nil {
+		var err error
+		ai.State, err = newScriptState(L, tbl, luaHandle(w, id))
+		errs = append(errs, err)
+	}
+	errs = append(errs, deliverEvents(w, tbl, ai, id))
+	fn := L.GetField(tbl, "update_state")
+	if fn.Type() == lua.LTFunction {
+		errs = append(errs, callBudgeted(L, ScriptBudget, 0, fn,
+			ai.State,
+			luaHandle(w, id),
+			lua.LNumber(s.Memory.X),
+			lua.LNumber(s.Memory.Y),
+			lua.LNumber(s.MemoryRadius),
+			lua.LNumber(s.Well.X),
+			lua.LNumber(s.Well.Y),
+		))
+	}
+	// The behaviour runs after update_state so its wait conditions see this tick's perception
+	errs = append(errs, runBehaviour(w, tbl, ai, id))
+	return errors.Join(errs...)
+}
+
+// reportAll hands each failure joined into err to diag, stopping at the first one strict mode refuses
+func reportAll(diag *ScriptDiagnostics, script string, id core.Entity, tick uint64, err error) error {
+	if err == nil { return nil }
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { errs = joined.Unwrap() }
+	for _, e := range errs {
+		if serr := diag.Report(newScriptError(script, id, tick, e)); serr != nil { return serr }
+	}
+	return nil
+}
+
+// newScriptState gives an entity its own script instance so entities sharing a script never share a brain
+func newScriptState(L *lua.LState, script lua.LValue, id lua.LNumber) (*lua.LTable, error) {
+	self := L.NewTable()
+	if fn := L.GetField(script, "init"); fn.Type() == lua.LTFunction {
+		// The entity keeps its (partially initialised) table either way so init does not rerun every frame
+		return self, callBudgeted(L, ScriptBudget, 0, fn, self, id)
+	}
+	return self, nil
+}
+
+// luaHandle is how scripts see an entity: its packed handle, so an ID a script holds on to
+// reads as gone once the entity is destroyed instead of naming whatever takes the slot next
+func luaHandle(w *world.World, id core.Entity) lua.LNumber {
+	return lua.LNumber(w.Handle(id).Pack())
+}
+
+// checkEntity reads argument n as the ID of a live entity, raising a Lua error otherwise
+func checkEntity(L *lua.LState, w *world.World, n int) core.Entity {
+	h := checkHandle(L, w, n)
+	id, ok := w.Resolve(h)
+	if !ok || !w.Exists(id) {
+		L.ArgError(n, fmt.Sprintf("entity %d does not exist", h.Pack()))
+	}
+	return id
+}
+
+// checkFinite reads argument n as a number that is neither NaN nor infinite. Either one in a
+// position or velocity spreads through the physics until a grid lookup indexes out of range.
+func checkFinite(L *lua.LState, n int) float64 {
+	v := float64(L.CheckNumber(n))
+	if math.IsNaN(v) || math.IsInf(v, 0) { L.ArgError(n, fmt.Sprintf("expected a finite number, got %v", v)) }
+	return v
+}
+
+// checkHandle only requires an ID the world could have handed out; the entity behind it may
+// already be destroyed, or be left over from an earlier level
+func checkHandle(L *lua.LState, w *world.World, n int) core.Handle {
+	v := float64(L.CheckNumber(n))
+	if v < 0 || v >= 1<<52 || v != math.Trunc(v) || !w.Issued(core.UnpackHandle(int64(v))) {
+		L.ArgError(n, fmt.Sprintf("invalid entity ID %v", v))
+	}
+	return core.UnpackHandle(int64(v))
 }
 
 func getScriptName(name string) string {
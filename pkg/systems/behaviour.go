@@ -0,0 +1,108 @@
+package systems
+
+import (
+	"fmt"
+	"math"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// everySrc is written in Lua because it has to loop across yields
+const everySrc = `
+function every(seconds, fn)
+	while fn() ~= false do wait(seconds) end
+end
+`
+
+// registerBehaviours adds the helpers a behave coroutine uses to pace itself on the fixed timestep.
+// A script opts in by defining behave(self, id) next to update_state; it starts on the entity's first
+// update and is resumed once per tick whenever what it is waiting for has happened.
+func registerBehaviours(L *lua.LState, w *world.World) {
+	// wait([seconds]) sleeps for at least one tick
+	L.SetGlobal("wait", L.NewFunction(func(L *lua.LState) int {
+		secs := float64(L.OptNumber(1, 0))
+		if !(secs >= 0) || math.IsInf(secs, 0) { L.ArgError(1, fmt.Sprintf("duration must be a finite number of at least 0, got %v", secs)) }
+		checkInBehaviour(L, "wait")
+		return L.Yield(lua.LNumber(w.Tick + max(1, world.TicksFor(secs))))
+	}))
+
+	// wait_until(fn [, timeout]) -> true once fn() returns true, or false if timeout seconds pass first
+	L.SetGlobal("wait_until", L.NewFunction(func(L *lua.LState) int {
+		cond := L.CheckFunction(1)
+		timeout := float64(L.OptNumber(2, 0))
+		if !(timeout >= 0) || math.IsInf(timeout, 0) { L.ArgError(2, fmt.Sprintf("timeout must be a finite number of at least 0, got %v", timeout)) }
+		checkInBehaviour(L, "wait_until")
+		deadline := uint64(0)
+		if L.GetTop() >= 2 { deadline = w.Tick + max(1, world.TicksFor(timeout)) }
+		return L.Yield(cond, lua.LNumber(deadline))
+	}))
+
+	if err := L.DoString(everySrc); err != nil {
+		panic(err)
+	}
+}
+
+// checkInBehaviour rejects a yield from update_state, which has no coroutine to suspend
+func checkInBehaviour(L *lua.LState, name string) {
+	if L.Parent == nil {
+		L.RaiseError("%s can only be called from a behave coroutine", name)
+	}
+}
+
+// runBehaviour advances an entity's behave coroutine by at most one resume
+func runBehaviour(w *world.World, script lua.LValue, ai *components.AI, id core.Entity) error {
+	L := w.LState
+	fn, ok := L.GetField(script, "behave").(*lua.LFunction)
+	if !ok { return nil }
+
+	b := ai.Behaviour
+	var args []lua.LValue
+	if b == nil || b.Fn != fn {
+		// First run, or a hot reload replaced behave: start over from the top with the new code
+		co, _ := L.NewThread()
+		b = &components.Coroutine{Thread: co, Fn: fn}
+		ai.Behaviour = b
+		args = []lua.LValue{ai.State, luaHandle(w, id)}
+	} else {
+		if b.Done || w.Tick < b.WakeTick { return nil }
+		if b.Until != nil {
+			if err := callBudgeted(L, ScriptBudget, 1, b.Until); err != nil {
+				b.Done = true
+				return err
+			}
+			met := lua.LVAsBool(L.Get(-1))
+			L.Pop(1)
+			if !met && (b.Deadline == 0 || w.Tick < b.Deadline) { return nil }
+			// wait_until returns whether the condition, rather than the timeout, woke it
+			args = []lua.LValue{lua.LBool(met)}
+		}
+	}
+
+	st, values, err := resumeBudgeted(L, b.Thread, ScriptBudget, fn, args...)
+	b.Until, b.Deadline = nil, 0
+	switch {
+	case err != nil:
+		b.Done = true
+		return err
+	case st == lua.ResumeOK:
+		// behave returned; the entity carries on with update_state alone
+		b.Done = true
+		return nil
+	}
+
+	switch {
+	case len(values) >= 1 && values[0].Type() == lua.LTNumber:
+		b.WakeTick = uint64(values[0].(lua.LNumber))
+	case len(values) >= 2 && values[0].Type() == lua.LTFunction:
+		b.Until = values[0].(*lua.LFunction)
+		b.Deadline = uint64(lua.LVAsNumber(values[1]))
+	default:
+		// A bare coroutine.yield() simply waits for the next tick
+		b.WakeTick = w.Tick + 1
+	}
+	return nil
+}
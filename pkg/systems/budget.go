@@ -0,0 +1,96 @@
+package systems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// ScriptBudget is how many VM instructions one entity's script may run per tick. Counting
+	// instructions rather than time cuts a script off at the same point on every machine, however
+	// busy, so replays and seeded runs cannot diverge over it. The shipped scripts stay under
+	// 2,000 a tick; reaching it means a runaway loop or a script doing far too much per tick.
+	ScriptBudget = 100_000
+	// ScriptLoadBudget bounds a file's top-level chunk, which runs once per load
+	ScriptLoadBudget = 1_000_000
+	// ScriptWatchdog is a wall-clock backstop for time spent inside Go bindings, which the
+	// instruction count cannot see. It is far beyond anything a script within budget takes, so it
+	// only ever ends a hang and never decides gameplay.
+	ScriptWatchdog = 250 * time.Millisecond
+)
+
+// ErrOverBudget is what a script that ran out of instructions fails with; errors.Is finds it in
+// the ScriptError reported for it
+var ErrOverBudget = errors.New("instruction budget exceeded")
+
+// closedChan is what Done returns once the budget is spent
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// stepBudget is the context a budgeted call runs under. The VM checks Done before every
+// instruction, so counting those checks counts instructions; the embedded context is the watchdog.
+type stepBudget struct {
+	context.Context
+	left int
+}
+
+func (b *stepBudget) Done() <-chan struct{} {
+	if b.left <= 0 { return closedChan }
+	b.left--
+	return b.Context.Done()
+}
+
+func (b *stepBudget) Err() error {
+	if b.left <= 0 { return ErrOverBudget }
+	return b.Context.Err()
+}
+
+func newStepBudget(steps int) (*stepBudget, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), ScriptWatchdog)
+	return &stepBudget{Context: ctx, left: steps}, cancel
+}
+
+// callBudgeted makes a protected call that the VM aborts after steps instructions.
+// Any nret results are left on the stack for the caller to pop.
+func callBudgeted(L *lua.LState, steps int, nret int, fn lua.LValue, args ...lua.LValue) error {
+	ctx, cancel := newStepBudget(steps)
+	defer cancel()
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+
+	err := L.CallByParam(lua.P{Fn: fn, NRet: nret, Protect: true}, args...)
+	return explainBudget(ctx, err, steps)
+}
+
+// resumeBudgeted resumes co under the same budget as a plain call
+func resumeBudgeted(L, co *lua.LState, steps int, fn *lua.LFunction, args ...lua.LValue) (lua.ResumeState, []lua.LValue, error) {
+	ctx, cancel := newStepBudget(steps)
+	defer cancel()
+	co.SetContext(ctx)
+	defer co.RemoveContext()
+
+	st, err, values := L.Resume(co, fn, args...)
+	return st, values, explainBudget(ctx, err, steps)
+}
+
+// explainBudget replaces the bare context error, which means nothing to a script author;
+// the traceback, when there is one, still shows where the script was stuck
+func explainBudget(ctx *stepBudget, err error, steps int) error {
+	var apiErr *lua.ApiError
+	if err == nil || !errors.As(err, &apiErr) { return err }
+	switch ctx.Err() {
+	case ErrOverBudget:
+		apiErr.Object = lua.LString(fmt.Sprintf("exceeded the budget of %d instructions", steps))
+		apiErr.Cause = ErrOverBudget
+	case context.DeadlineExceeded:
+		apiErr.Object = lua.LString(fmt.Sprintf("still running after %v, inside a binding", ScriptWatchdog))
+	}
+	return err
+}
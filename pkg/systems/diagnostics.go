@@ -0,0 +1,130 @@
+package systems
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"beautifulmess/pkg/core"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// NoEntity marks script errors raised outside any entity's update, e.g. while loading a file
+const NoEntity core.Entity = -1
+
+// ScriptError is one failure inside a behaviour script, with enough context to find it
+type ScriptError struct {
+	Script  string
+	Entity  core.Entity
+	Tick    uint64 // Sim tick of the latest occurrence
+	Message string
+	Trace   string // Lua stack traceback, when the VM provided one
+	Count   int    // Occurrences folded into this entry
+	// OverBudget means the script was cut off for running too long rather than failing outright
+	OverBudget bool
+}
+
+func (e *ScriptError) Error() string {
+	where := e.Script
+	if e.Entity != NoEntity {
+		where = fmt.Sprintf("%s (entity %d)", e.Script, e.Entity)
+	}
+	if e.Trace == "" {
+		return where + ": " + e.Message
+	}
+	return where + ": " + e.Message + "\n" + e.Trace
+}
+
+// Unwrap lets errors.Is(err, ErrOverBudget) pick out budget trips
+func (e *ScriptError) Unwrap() error {
+	if e.OverBudget { return ErrOverBudget }
+	return nil
+}
+
+func (e *ScriptError) key() string { return e.Script + "\x00" + e.Message }
+
+// newScriptError splits a gopher-lua error into message and traceback
+func newScriptError(script string, id core.Entity, tick uint64, err error) *ScriptError {
+	se := &ScriptError{Script: script, Entity: id, Tick: tick, Message: err.Error(), Count: 1}
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) {
+		se.Message, se.Trace = apiErr.Object.String(), apiErr.StackTrace
+		se.OverBudget = apiErr.Cause == ErrOverBudget
+	}
+	return se
+}
+
+// MaxRecentScriptErrors bounds what the developer overlay keeps
+const MaxRecentScriptErrors = 8
+
+// ScriptDiagnostics collects script failures. A broken script fails every frame, so the log is
+// rate-limited per distinct error while the overlay keeps a running count instead.
+type ScriptDiagnostics struct {
+	Strict      bool          // The first error aborts the level instead of being tolerated
+	LogInterval time.Duration // Minimum gap between log lines for the same error
+	Recent      []*ScriptError
+	lastLogged  map[string]time.Time
+	suppressed  map[string]int
+}
+
+func NewScriptDiagnostics(strict bool) *ScriptDiagnostics {
+	return &ScriptDiagnostics{
+		Strict:      strict,
+		LogInterval: 5 * time.Second,
+		lastLogged:  make(map[string]time.Time),
+		suppressed:  make(map[string]int),
+	}
+}
+
+// Report records err and returns it when strict mode requires the caller to stop
+func (d *ScriptDiagnostics) Report(se *ScriptError) error {
+	if d == nil {
+		log.Printf("lua: %v", se)
+		return nil
+	}
+
+	k := se.key()
+	merged := false
+	for _, r := range d.Recent {
+		if r.key() == k {
+			r.Count++
+			r.Tick, r.Entity = se.Tick, se.Entity
+			merged = true
+			break
+		}
+	}
+	if !merged {
+		d.Recent = append(d.Recent, se)
+		if len(d.Recent) > MaxRecentScriptErrors {
+			d.Recent = d.Recent[1:]
+		}
+	}
+
+	if last, ok := d.lastLogged[k]; ok && time.Since(last) < d.LogInterval {
+		d.suppressed[k]++
+	} else {
+		if n := d.suppressed[k]; n > 0 {
+			log.Printf("lua: previous error repeated %d more times", n)
+		}
+		log.Printf("lua: %v", se)
+		d.lastLogged[k], d.suppressed[k] = time.Now(), 0
+	}
+
+	if d.Strict {
+		return se
+	}
+	return nil
+}
+
+// ClearScript forgets errors from one script, e.g. once a fixed version has been reloaded
+func (d *ScriptDiagnostics) ClearScript(script string) {
+	kept := d.Recent[:0]
+	for _, r := range d.Recent {
+		if r.Script != script {
+			kept = append(kept, r)
+		}
+	}
+	d.Recent = kept
+}
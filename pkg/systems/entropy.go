@@ -18,17 +18,15 @@ func SystemEntropy(w *world.World, frostMask *image.RGBA) {
 		}
 	}
 
-	for id, trans := range w.Transforms {
-		if trans == nil { continue }
-		render := w.Renders[id]
-		if render == nil { continue }
+	for id := range world.Query(w.Transforms, w.Renders) {
+		trans := w.Transforms.Get(id)
 
 		// Coordinate remapping translates world-space motion into low-res texture memory
 		sx := int(trans.Position.X * (float64(core.MistWidth) / core.ScreenWidth))
 		sy := int(trans.Position.Y * (float64(core.MistHeight) / core.ScreenHeight))
 
 		rad := 2
-		if tag := w.Tags[id]; tag != nil && tag.Name == "spectre" {
+		if tag := w.Tags.Get(id); tag != nil && tag.Name == "spectre" {
 			rad = 3 // Larger trails emphasize the spectre's heavy presence
 		}
 
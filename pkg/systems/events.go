@@ -0,0 +1,145 @@
+package systems
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/particles"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// MaxScriptEvents caps how many events an entity's script is handed per tick; a bullet rattling
+// around a corridor should not cost more script time than the rest of the level
+const MaxScriptEvents = 16
+
+// SubscribeFeedback plays the sound, shake and debris that make hits land. Headless worlds leave
+// it out, since nothing there is seen or heard.
+func SubscribeFeedback(w *world.World) {
+	world.Subscribe(w, func(e world.SpectreHit) {
+		w.Audio.Play("boom")
+		w.ScreenShake += 8.0
+		// The spectre survives the hit, so it still has its colour to shed
+		if id, ok := w.Resolve(e.Spectre); ok {
+			if render := w.Renders.Get(id); render != nil { shatter(w, e.Position, render.Color, e.Velocity) }
+		}
+	})
+	world.Subscribe(w, func(e world.WallShattered) {
+		if e.Color.A != 0 { shatter(w, e.Position, e.Color, e.Velocity) }
+		w.Audio.Play("boom")
+		w.ScreenShake += 4.0
+	})
+	world.Subscribe(w, func(e world.BulletBounced) {
+		// High-frequency flickering sparks convey the hardness of indestructible surfaces
+		spawnDebrisQuirky(w, e.Position, color.RGBA{200, 200, 255, 255}, 5, 2.0, core.Vector2{}, particles.QuirkFlicker)
+		w.ScreenShake += 1.0
+	})
+}
+
+func shatter(w *world.World, pos core.Vector2, col color.RGBA, impactVel core.Vector2) {
+	// Specialized particle quirks provide a high-fidelity 'Nintendo-grade' destruction feel
+	// Core explosion with inherited momentum
+	biasVel := core.Vector2{X: impactVel.X * 0.2, Y: impactVel.Y * 0.2}
+	spawnDebrisQuirky(w, pos, col, 15, 4.0, biasVel, particles.QuirkStandard)
+
+	// 'Orphaned' data fragments that orbit the blast center create visual complexity
+	spawnDebrisQuirky(w, pos, color.RGBA{255, 255, 200, 255}, 6, 6.0, biasVel, particles.QuirkOrbit)
+
+	// Flickering sparks simulate energetic discharge
+	spawnDebrisQuirky(w, pos, col, 10, 3.0, biasVel, particles.QuirkFlicker)
+}
+
+func spawnDebrisQuirky(w *world.World, pos core.Vector2, col color.RGBA, count int, maxSpeed float64, bias core.Vector2, quirk particles.ParticleQuirk) {
+	for i := 0; i < count; i++ {
+		angle := rand.Float64() * 2 * math.Pi
+		speed := rand.Float64() * maxSpeed
+
+		vel := core.Vector2{
+			X: math.Cos(angle)*speed + bias.X,
+			Y: math.Sin(angle)*speed + bias.Y,
+		}
+
+		w.Particles.EmitAdvanced(
+			pos,
+			vel,
+			col,
+			0.01+rand.Float64()*0.04,
+			quirk,
+		)
+	}
+}
+
+// subscribeScripts forwards events to every script that defines on_event(self, id, ev). ev.name
+// says what happened ("spectre_hit", "wall_shattered", "bullet_bounced" or "spectre_caught"), ev.x
+// and ev.y where, and the other fields name the entities involved; they may already be destroyed.
+// Scripts hear about events at their next update, before update_state.
+func subscribeScripts(w *world.World) {
+	L := w.LState
+	world.Subscribe(w, func(e world.SpectreHit) {
+		ev := eventTable(L, "spectre_hit", e.Position)
+		L.SetField(ev, "spectre", lua.LNumber(e.Spectre.Pack()))
+		L.SetField(ev, "bullet", lua.LNumber(e.Bullet.Pack()))
+		queueScriptEvent(w, ev)
+	})
+	world.Subscribe(w, func(e world.WallShattered) {
+		ev := eventTable(L, "wall_shattered", e.Position)
+		L.SetField(ev, "wall", lua.LNumber(e.Wall.Pack()))
+		L.SetField(ev, "bullet", lua.LNumber(e.Bullet.Pack()))
+		queueScriptEvent(w, ev)
+	})
+	world.Subscribe(w, func(e world.BulletBounced) {
+		ev := eventTable(L, "bullet_bounced", e.Position)
+		L.SetField(ev, "bullet", lua.LNumber(e.Bullet.Pack()))
+		L.SetField(ev, "wall", lua.LNumber(e.Wall.Pack()))
+		queueScriptEvent(w, ev)
+	})
+	world.Subscribe(w, func(e world.SpectreCaught) {
+		ev := eventTable(L, "spectre_caught", e.Position)
+		L.SetField(ev, "spectre", lua.LNumber(e.Spectre.Pack()))
+		L.SetField(ev, "runner", lua.LNumber(e.Runner.Pack()))
+		queueScriptEvent(w, ev)
+	})
+}
+
+func eventTable(L *lua.LState, name string, pos core.Vector2) *lua.LTable {
+	ev := L.NewTable()
+	L.SetField(ev, "name", lua.LString(name))
+	L.SetField(ev, "x", lua.LNumber(pos.X))
+	L.SetField(ev, "y", lua.LNumber(pos.Y))
+	return ev
+}
+
+// queueScriptEvent puts ev in the inbox of every scripted entity that listens; the table is
+// shared between them
+func queueScriptEvent(w *world.World, ev *lua.LTable) {
+	for _, ai := range w.AIs.All() {
+		if ai.ScriptName == "" || ai.Driver == components.DriverNative || len(ai.Inbox) >= MaxScriptEvents { continue }
+		tbl := w.LState.GetGlobal(getScriptName(ai.ScriptName))
+		if tbl.Type() != lua.LTTable || w.LState.GetField(tbl, "on_event").Type() != lua.LTFunction { continue }
+		ai.Inbox = append(ai.Inbox, ev)
+	}
+}
+
+// deliverEvents empties ai's inbox into the script's on_event
+func deliverEvents(w *world.World, tbl lua.LValue, ai *components.AI, id core.Entity) error {
+	if len(ai.Inbox) == 0 { return nil }
+	defer func() {
+		clear(ai.Inbox)
+		ai.Inbox = ai.Inbox[:0]
+	}()
+	L := w.LState
+	fn := L.GetField(tbl, "on_event")
+	if fn.Type() != lua.LTFunction { return nil }
+	var errs []error
+	for _, ev := range ai.Inbox {
+		if err := callBudgeted(L, ScriptBudget, 0, fn, ai.State, luaHandle(w, id), ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
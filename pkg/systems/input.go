@@ -8,24 +8,18 @@ import (
 	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
 	"beautifulmess/pkg/world"
-
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func SystemInput(w *world.World) {
-	for e, controlled := range w.InputControlleds {
-		if controlled == nil { continue }
-		
-		phys := w.Physics[e]
-		trans := w.Transforms[e]
-		if phys == nil || trans == nil { continue }
+	for e := range world.Query(w.InputControlleds, w.Physics, w.Transforms) {
+		phys := w.Physics.Get(e)
+		trans := w.Transforms.Get(e)
 
 		// Dynamic speed limits enable the 'overdrive' mechanic, providing physical gratification for skill-based timing
 		baseMaxSpeed := 7.5
-		accel, input := 1.5, core.Vector2{}
+		accel := 1.5
 		
-		// Supporting multiple key bindings ensures accessibility and comfort for different user grip styles
-		if ebiten.IsKeyPressed(ebiten.KeyShift) || ebiten.IsKeyPressed(ebiten.KeyC) {
+		if w.Input.Boost() {
 			accel = 4.5 // Increased from 3.5 for more immediate responsiveness
 			phys.MaxSpeed = baseMaxSpeed * 2.0 
 			w.Audio.Play("boost")
@@ -36,10 +30,7 @@ func SystemInput(w *world.World) {
 		}
 
 		// Calculating an explicit input vector separates player intent from physical momentum
-		if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) || ebiten.IsKeyPressed(ebiten.KeyA) { input.X -= 1 }
-		if ebiten.IsKeyPressed(ebiten.KeyArrowRight) || ebiten.IsKeyPressed(ebiten.KeyD) { input.X += 1 }
-		if ebiten.IsKeyPressed(ebiten.KeyArrowUp) || ebiten.IsKeyPressed(ebiten.KeyW) { input.Y -= 1 }
-		if ebiten.IsKeyPressed(ebiten.KeyArrowDown) || ebiten.IsKeyPressed(ebiten.KeyS) { input.Y += 1 }
+		input := w.Input.MoveDir()
 
 		phys.Acceleration.X += input.X * accel
 		phys.Acceleration.Y += input.Y * accel
@@ -6,11 +6,9 @@ import (
 )
 
 func SystemLifetime(w *world.World) {
-	dt := 1.0 / 60.0 
+	dt := core.TimeStep
 
-	for id, life := range w.Lifetimes {
-		if life == nil { continue }
-		
+	for id, life := range w.Lifetimes.All() {
 		life.TimeRemaining -= dt
 		if life.TimeRemaining <= 0 {
 			// Automated cleanup prevents memory fragmentation and logic leaks over time
@@ -0,0 +1,148 @@
+package systems
+
+import (
+	"math"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/world"
+)
+
+// Brain decides an entity's forces for one tick. Scripts and their native Go counterparts both
+// implement it so SystemAI can swap one for the other mid-level.
+type Brain interface {
+	// Update may report several failures at once (joined with errors.Join) and keep going past them
+	Update(w *world.World, id core.Entity, ai *components.AI, s Senses) error
+}
+
+// Senses is the perception SystemAI gathers for an entity before its brain runs
+type Senses struct {
+	Memory       core.Vector2
+	MemoryRadius float64
+	Well         core.Vector2 // Nearest gravity well; the origin when the level has none
+}
+
+// FallbackAfter is how many consecutive failing ticks a script gets before DriverAuto hands its
+// entity to the native brain. A successful hot reload gives the script another chance.
+const FallbackAfter = 30
+
+// NativeBrains are Go implementations of scripts, keyed by the script they stand in for
+var NativeBrains = map[string]Brain{
+	"spectre.lua": NativeSpectre{},
+}
+
+type spectreState int
+
+const (
+	spectreCruise spectreState = iota
+	spectreSprint
+	spectreJink
+	spectreRecover
+)
+
+const spectreMaxStamina = 100.0
+
+// spectreMemory is the native counterpart of the script's self table
+type spectreMemory struct {
+	state   spectreState
+	timer   int
+	stamina float64
+	jinkDir float64
+}
+
+// NativeSpectre is the cruise/sprint/jink/recover spectre written in Go. It plays the classic
+// behaviour whatever the chapter's personality, so a missing script still leaves a fair chase.
+type NativeSpectre struct{}
+
+func (NativeSpectre) Update(w *world.World, id core.Entity, ai *components.AI, s Senses) error {
+	mem, ok := ai.Native.(*spectreMemory)
+	if !ok {
+		mem = &spectreMemory{state: spectreCruise, stamina: spectreMaxStamina, jinkDir: 1}
+		ai.Native = mem
+	}
+	phys, trans := w.Physics.Get(id), w.Transforms.Get(id)
+	if phys == nil || trans == nil { return nil }
+
+	// Without a target there is nothing to flee; drift like the script would if it could not see
+	target, ok := w.Resolve(ai.TargetID)
+	if !ok || !w.Exists(target) {
+		phys.Acceleration.X += phys.Velocity.X * 0.1
+		phys.Acceleration.Y += phys.Velocity.Y * 0.1
+		return nil
+	}
+	toOpp, dist := unitTo(trans.Position, w.Transforms.Get(target).Position)
+
+	mem.timer--
+	// Stamina regeneration prevents infinite sprinting and encourages tactical retreats
+	if mem.state != spectreSprint { mem.stamina = math.Min(spectreMaxStamina, mem.stamina+0.5) }
+
+	// Threat-response logic triggers evasion when the runner enters the spectre's personal space
+	if dist < 120 && mem.state == spectreCruise {
+		if mem.stamina > 30 {
+			mem.state, mem.timer, mem.jinkDir = spectreJink, 15, 1
+			if w.RNG.Float64() < 0.5 { mem.jinkDir = -1 }
+			w.Audio.Play("spectre_dash")
+		} else {
+			mem.state, mem.timer = spectreRecover, 40
+		}
+	}
+
+	// State transitions are timer-based to ensure rhythmic movement cycles
+	if mem.timer <= 0 {
+		switch mem.state {
+		case spectreSprint:
+			mem.state, mem.timer = spectreRecover, 30
+		case spectreJink:
+			mem.state, mem.timer = spectreSprint, 40
+		case spectreRecover:
+			mem.state = spectreCruise
+		}
+	}
+
+	// Fleeing the memory node mirrors the script's survival instinct, jitter included
+	toMem, memDist := unitTo(trans.Position, s.Memory)
+	if memDist < s.MemoryRadius {
+		phys.Acceleration.X += -toMem.X*2.5 + (w.RNG.Float64()-0.5)*4
+		phys.Acceleration.Y += -toMem.Y*2.5 + (w.RNG.Float64()-0.5)*4
+		return nil
+	}
+
+	var fx, fy float64
+	switch mem.state {
+	case spectreCruise:
+		phys.MaxSpeed = 4.0
+		fx, fy = -toOpp.X*0.5, -toOpp.Y*0.5
+		// Singularity avoidance simulates active engine compensation against gravitational pull
+		if toWell, wellDist := unitTo(trans.Position, s.Well); wellDist < 400 {
+			fx, fy = fx-toWell.X*1.2, fy-toWell.Y*1.2
+		}
+	case spectreSprint:
+		phys.MaxSpeed = 9.0
+		mem.stamina -= 2.0
+		fx, fy = -toOpp.X*2.0, -toOpp.Y*2.0
+		if mem.stamina <= 0 { mem.state, mem.timer = spectreRecover, 60 }
+	case spectreJink:
+		phys.MaxSpeed = 12.0
+		mem.stamina -= 1.0
+		// Perpendicular vectors create lateral movement to break target locks
+		fx, fy = -toOpp.Y*mem.jinkDir*3.0, toOpp.X*mem.jinkDir*3.0
+	case spectreRecover:
+		phys.MaxSpeed = 3.0
+		fx, fy = -toOpp.X*0.8, -toOpp.Y*0.8
+	}
+
+	// Velocity damping prevents infinite drifting in the void
+	if math.Abs(fx) < 0.1 && math.Abs(fy) < 0.1 {
+		fx, fy = phys.Velocity.X*0.1, phys.Velocity.Y*0.1
+	}
+	phys.Acceleration.X += fx
+	phys.Acceleration.Y += fy
+	return nil
+}
+
+// unitTo is get_vec_to for Go brains: the wrapped direction to target and the distance to it
+func unitTo(from, target core.Vector2) (core.Vector2, float64) {
+	delta := core.VecToWrapped(from, target)
+	d := math.Max(0.01, math.Sqrt(delta.X*delta.X+delta.Y*delta.Y))
+	return core.Vector2{X: delta.X / d, Y: delta.Y / d}, d
+}
@@ -0,0 +1,121 @@
+package systems
+
+import (
+	"fmt"
+	"math"
+
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// registerPerception exposes spatial queries so scripts can reason about cover and obstacles.
+// Every list is an array of tables sorted nearest first.
+func registerPerception(L *lua.LState, w *world.World) {
+	// get_walls_near(x, y, radius) -> { {id, x, y, dist, destructible}, ... }
+	L.SetGlobal("get_walls_near", L.NewFunction(func(L *lua.LState) int {
+		p := core.Vector2{X: checkFinite(L, 1), Y: checkFinite(L, 2)}
+		hits := w.WallsNear(p, checkDistance(L, 3))
+		list := L.CreateTable(len(hits), 0)
+		for _, h := range hits {
+			t := hitTable(L, w, h)
+			t.RawSetString("destructible", lua.LBool(w.Walls.Get(h.ID).Destructible))
+			list.Append(t)
+		}
+		L.Push(list)
+		return 1
+	}))
+
+	// get_wells() -> { {id, x, y, radius, mass}, ... } in entity order
+	L.SetGlobal("get_wells", L.NewFunction(func(L *lua.LState) int {
+		wells := w.Wells()
+		list := L.CreateTable(len(wells), 0)
+		for _, id := range wells {
+			pos, well := w.Transforms.Get(id).Position, w.GravityWells.Get(id)
+			t := L.CreateTable(0, 5)
+			t.RawSetString("id", luaHandle(w, id))
+			t.RawSetString("x", lua.LNumber(pos.X))
+			t.RawSetString("y", lua.LNumber(pos.Y))
+			t.RawSetString("radius", lua.LNumber(well.Radius))
+			t.RawSetString("mass", lua.LNumber(well.Mass))
+			list.Append(t)
+		}
+		L.Push(list)
+		return 1
+	}))
+
+	// raycast(x, y, dx, dy, max_dist) -> hit, hit_x, hit_y, dist, wall_id
+	L.SetGlobal("raycast", L.NewFunction(func(L *lua.LState) int {
+		origin := core.Vector2{X: checkFinite(L, 1), Y: checkFinite(L, 2)}
+		dir := core.Vector2{X: checkFinite(L, 3), Y: checkFinite(L, 4)}
+		h, ok := w.Raycast(origin, dir, checkDistance(L, 5))
+		if !ok {
+			L.Push(lua.LFalse)
+			return 1
+		}
+		L.Push(lua.LTrue)
+		L.Push(lua.LNumber(h.Pos.X))
+		L.Push(lua.LNumber(h.Pos.Y))
+		L.Push(lua.LNumber(h.Dist))
+		L.Push(luaHandle(w, h.ID))
+		return 5
+	}))
+
+	// trace_shot(x, y, dx, dy, target_x, target_y, max_dist [, max_bounces]) -> miss, dist, bounces
+	// Follows a bullet through its wall bounces and reports its closest approach to the target.
+	L.SetGlobal("trace_shot", L.NewFunction(func(L *lua.LState) int {
+		origin := core.Vector2{X: checkFinite(L, 1), Y: checkFinite(L, 2)}
+		dir := core.Vector2{X: checkFinite(L, 3), Y: checkFinite(L, 4)}
+		target := core.Vector2{X: checkFinite(L, 5), Y: checkFinite(L, 6)}
+		bounces := L.OptInt(8, 2)
+		if bounces < 0 { L.ArgError(8, fmt.Sprintf("max_bounces must be at least 0, got %d", bounces)) }
+		shot := w.TraceShot(origin, dir, target, checkDistance(L, 7), bounces)
+		L.Push(lua.LNumber(shot.Miss))
+		L.Push(lua.LNumber(shot.Dist))
+		L.Push(lua.LNumber(shot.Bounces))
+		return 3
+	}))
+
+	// get_vec_between(ax, ay, bx, by) -> dx, dy, dist: get_vec_to for two arbitrary points
+	L.SetGlobal("get_vec_between", L.NewFunction(func(L *lua.LState) int {
+		a := core.Vector2{X: checkFinite(L, 1), Y: checkFinite(L, 2)}
+		b := core.Vector2{X: checkFinite(L, 3), Y: checkFinite(L, 4)}
+		dir, d := unitTo(a, b)
+		L.Push(lua.LNumber(dir.X))
+		L.Push(lua.LNumber(dir.Y))
+		L.Push(lua.LNumber(d))
+		return 3
+	}))
+
+	// find_by_tag(tag, x, y, radius) -> { {id, x, y, dist}, ... }
+	L.SetGlobal("find_by_tag", L.NewFunction(func(L *lua.LState) int {
+		tag := L.CheckString(1)
+		p := core.Vector2{X: checkFinite(L, 2), Y: checkFinite(L, 3)}
+		hits := w.EntitiesByTag(tag, p, checkDistance(L, 4))
+		list := L.CreateTable(len(hits), 0)
+		for _, h := range hits {
+			list.Append(hitTable(L, w, h))
+		}
+		L.Push(list)
+		return 1
+	}))
+}
+
+func hitTable(L *lua.LState, w *world.World, h world.Hit) *lua.LTable {
+	t := L.CreateTable(0, 4)
+	t.RawSetString("id", luaHandle(w, h.ID))
+	t.RawSetString("x", lua.LNumber(h.Pos.X))
+	t.RawSetString("y", lua.LNumber(h.Pos.Y))
+	t.RawSetString("dist", lua.LNumber(h.Dist))
+	return t
+}
+
+// checkDistance reads argument n as a range, which must be finite and not negative
+func checkDistance(L *lua.LState, n int) float64 {
+	v := float64(L.CheckNumber(n))
+	if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+		L.ArgError(n, fmt.Sprintf("distance must be a finite number of at least 0, got %v", v))
+	}
+	return v
+}
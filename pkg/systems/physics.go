@@ -1,52 +1,56 @@
 package systems
 
 import (
-	"image/color"
 	"math"
-	"math/rand"
 
 	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
-	"beautifulmess/pkg/particles"
 	"beautifulmess/pkg/world"
 )
 
 func SystemPhysics(w *world.World, easyMode bool, startAnimation bool) {
+	// Spectres are what bullets hit and home in on; finding them once keeps every bullet's checks short
+	spectres := findSpectres(w)
+
 	// Cache-friendly sequential iteration over component slices minimizes CPU pipeline stalls
-	for id, phys := range w.Physics {
-		if phys == nil { continue }
-		trans := w.Transforms[id]
-		if trans == nil { continue }
+	for id := range world.Query(w.Physics, w.Transforms) {
+		phys, trans := w.Physics.Get(id), w.Transforms.Get(id)
 
-		if !startAnimation && easyMode && w.Tags[id] != nil && w.Tags[id].Name == "bullet" {
-			applyHoming(core.Entity(id), w)
+		if tag := w.Tags.Get(id); !startAnimation && easyMode && tag != nil && tag.Name == "bullet" {
+			applyHoming(id, w, spectres)
 		}
 
 		if !startAnimation {
-			applyForces(core.Entity(id), w)
+			applyForces(id, w)
 		}
 		
 		integrate(phys, trans, startAnimation)
 		core.WrapPosition(&trans.Position)
-		handleCollisions(core.Entity(id), w)
+		handleCollisions(id, w, spectres)
+	}
+}
+
+func findSpectres(w *world.World) []core.Entity {
+	var ids []core.Entity
+	for id := range world.Query(w.Tags, w.Transforms, w.Physics) {
+		if w.Tags.Get(id).Name == "spectre" { ids = append(ids, id) }
 	}
+	return ids
 }
 
-func applyHoming(id core.Entity, w *world.World) {
-	phys := w.Physics[id]
-	trans := w.Transforms[id]
+func applyHoming(id core.Entity, w *world.World, spectres []core.Entity) {
+	phys := w.Physics.Get(id)
+	trans := w.Transforms.Get(id)
 	if phys == nil || trans == nil { return }
 
 	// Find the spectre
 	var targetPos core.Vector2
 	found := false
-	for sid, tag := range w.Tags {
-		if tag != nil && tag.Name == "spectre" {
-			if st := w.Transforms[sid]; st != nil {
-				targetPos = st.Position
-				found = true
-				break
-			}
+	for _, sid := range spectres {
+		if st := w.Transforms.Get(sid); st != nil {
+			targetPos = st.Position
+			found = true
+			break
 		}
 	}
 
@@ -62,17 +66,17 @@ func applyHoming(id core.Entity, w *world.World) {
 }
 
 func applyForces(id core.Entity, w *world.World) {
-	phys := w.Physics[id]
-	trans := w.Transforms[id]
+	phys := w.Physics.Get(id)
+	trans := w.Transforms.Get(id)
 
 	// Bullets move at high velocities and effectively ignore gravitational curvature
-	if tag := w.Tags[id]; tag != nil && tag.Name == "bullet" {
+	if tag := w.Tags.Get(id); tag != nil && tag.Name == "bullet" {
 		return
 	}
 
-	for wellID, well := range w.GravityWells {
-		if well == nil || int(id) == wellID { continue }
-		wellTrans := w.Transforms[wellID]
+	for wellID, well := range w.GravityWells.All() {
+		if id == wellID { continue }
+		wellTrans := w.Transforms.Get(wellID)
 		if wellTrans == nil { continue }
 
 		delta := core.VecToWrapped(trans.Position, wellTrans.Position)
@@ -113,12 +117,12 @@ func integrate(phys *components.Physics, trans *components.Transform, bypassClam
 }
 
 
-func handleCollisions(id core.Entity, w *world.World) {
-	trans := w.Transforms[id]
-	phys := w.Physics[id]
+func handleCollisions(id core.Entity, w *world.World, spectres []core.Entity) {
+	trans := w.Transforms.Get(id)
+	phys := w.Physics.Get(id)
 	if trans == nil || phys == nil { return }
 	
-	tag := w.Tags[id]
+	tag := w.Tags.Get(id)
 
 	// Querying the spatial grid neighbor-cells handles toroidal-wrapped collisions with zero overhead
 	gx, gy := int(trans.Position.X/100), int(trans.Position.Y/100)
@@ -128,9 +132,9 @@ func handleCollisions(id core.Entity, w *world.World) {
 			tx, ty := (gx+dx+13)%13, (gy+dy+8)%8
 			
 			for _, wallID := range w.Grid[tx][ty] {
-				wall := w.Walls[wallID]
+				wall := w.Walls.Get(wallID)
 				if wall == nil || wall.IsDestroyed { continue }
-				wallTrans := w.Transforms[wallID]
+				wallTrans := w.Transforms.Get(wallID)
 				if wallTrans == nil { continue }
 
 				delta := core.VecToWrapped(trans.Position, wallTrans.Position)
@@ -147,13 +151,12 @@ func handleCollisions(id core.Entity, w *world.World) {
 						}
 
 						if wall.Destructible {
-							shatterEntity(w, wallID, phys.Velocity)
-							w.Audio.Play("boom") 
-							w.ScreenShake += 4.0
+							ev := world.WallShattered{Wall: w.Handle(wallID), Bullet: w.Handle(id), Position: wallTrans.Position, Velocity: phys.Velocity}
+							if render := w.Renders.Get(wallID); render != nil { ev.Color = render.Color }
+							world.Publish(w, ev)
 							w.DestroyEntity(wallID)
 						} else {
-							emitImpactFeedback(w, trans.Position)
-							w.ScreenShake += 1.0
+							world.Publish(w, world.BulletBounced{Bullet: w.Handle(id), Wall: w.Handle(wallID), Position: trans.Position})
 						}
 						return
 					}
@@ -164,98 +167,16 @@ func handleCollisions(id core.Entity, w *world.World) {
 	}
 
 	if tag != nil && tag.Name == "bullet" {
-		for _, specID := range w.ActiveEntities {
-			specTag := w.Tags[specID]
-			if specTag == nil || specTag.Name != "spectre" { continue }
-			
-			specTrans, specPhys := w.Transforms[specID], w.Physics[specID]
-			if specTrans != nil && specPhys != nil {
-				// 400 represents the squared radius (20^2), providing a zero-sqrt hit-detection path
-				if core.DistSqWrapped(trans.Position, specTrans.Position) < 400 {
-					specPhys.GravityMultiplier += 1.0
-					w.Audio.Play("boom")
-					w.ScreenShake += 8.0
-					shatterEntity(w, specID, phys.Velocity)
-					w.DestroyEntity(id)
-					return
-				}
+		for _, specID := range spectres {
+			specTrans, specPhys := w.Transforms.Get(specID), w.Physics.Get(specID)
+			if specTrans == nil || specPhys == nil { continue }
+			// 400 represents the squared radius (20^2), providing a zero-sqrt hit-detection path
+			if core.DistSqWrapped(trans.Position, specTrans.Position) < 400 {
+				specPhys.GravityMultiplier += 1.0
+				world.Publish(w, world.SpectreHit{Spectre: w.Handle(specID), Bullet: w.Handle(id), Position: specTrans.Position, Velocity: phys.Velocity})
+				w.DestroyEntity(id)
+				return
 			}
 		}
 	}
 }
-
-
-
-
-
-func shatterEntity(w *world.World, id core.Entity, impactVel core.Vector2) {
-
-
-	// Specialized particle quirks provide a high-fidelity 'Nintendo-grade' destruction feel
-
-
-	trans := w.Transforms[id]
-
-
-	render := w.Renders[id]
-
-
-	if trans == nil || render == nil { return }
-
-
-
-
-
-	// Core explosion with inherited momentum
-
-
-	biasVel := core.Vector2{X: impactVel.X * 0.2, Y: impactVel.Y * 0.2}
-
-
-	spawnDebrisQuirky(w, trans.Position, render.Color, 15, 4.0, biasVel, particles.QuirkStandard)
-
-
-	
-
-
-	// 'Orphaned' data fragments that orbit the blast center create visual complexity
-
-
-	spawnDebrisQuirky(w, trans.Position, color.RGBA{255, 255, 200, 255}, 6, 6.0, biasVel, particles.QuirkOrbit)
-
-
-	
-
-
-	// Flickering sparks simulate energetic discharge
-
-
-	spawnDebrisQuirky(w, trans.Position, render.Color, 10, 3.0, biasVel, particles.QuirkFlicker)
-
-
-}
-
-func emitImpactFeedback(w *world.World, pos core.Vector2) {
-	// High-frequency flickering sparks convey the hardness of indestructible surfaces
-	spawnDebrisQuirky(w, pos, color.RGBA{200, 200, 255, 255}, 5, 2.0, core.Vector2{}, particles.QuirkFlicker)
-}
-
-func spawnDebrisQuirky(w *world.World, pos core.Vector2, col color.RGBA, count int, maxSpeed float64, bias core.Vector2, quirk particles.ParticleQuirk) {
-	for i := 0; i < count; i++ {
-		angle := rand.Float64() * 2 * math.Pi
-		speed := rand.Float64() * maxSpeed
-		
-		vel := core.Vector2{
-			X: math.Cos(angle)*speed + bias.X,
-			Y: math.Sin(angle)*speed + bias.Y,
-		}
-		
-		w.Particles.EmitAdvanced(
-			pos,
-			vel,
-			col,
-			0.01 + rand.Float64()*0.04,
-			quirk,
-		)
-	}
-}		        
@@ -0,0 +1,72 @@
+package systems
+
+import (
+	"image/color"
+	"math"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/world"
+)
+
+// SpawnBullet creates a projectile at pos; it bounces off walls and marks any spectre it touches
+func SpawnBullet(w *world.World, pos, vel core.Vector2) core.Entity {
+	id := w.CreateEntity()
+	w.Transforms.Add(id, &components.Transform{Position: pos, Rotation: math.Atan2(vel.Y, vel.X)})
+
+	w.Physics.Add(id, &components.Physics{
+		Velocity: vel,
+		MaxSpeed: 20.0,
+		Mass:     5.0,
+		Friction: 1.0,
+	})
+
+	w.Renders.Add(id, &components.Render{
+		Sprite: generateBulletSprite(w),
+		Color:  color.RGBA{255, 255, 255, 255},
+		Scale:  0.5,
+		Layer:  components.LayerBullets,
+	})
+
+	// Temporary lifespan prevents stale projectiles from impacting future gameplay states
+	w.Lifetimes.Add(id, &components.Lifetime{TimeRemaining: 2.0})
+	w.Tags.Add(id, &components.Tag{Name: "bullet"})
+	return id
+}
+
+// SpawnWall places one 10px wall block; destructible blocks shatter when shot
+func SpawnWall(w *world.World, pos core.Vector2, destructible bool) core.Entity {
+	id := w.CreateEntity()
+	w.Transforms.Add(id, &components.Transform{Position: pos})
+	w.Walls.Add(id, &components.Wall{Size: 10, Destructible: destructible})
+	c := color.RGBA{0, 255, 255, 255}
+	if destructible { c = color.RGBA{255, 150, 50, 255} }
+	img := w.Gfx.NewSolidSprite(10, 10, c)
+	w.Renders.Add(id, &components.Render{Sprite: img, Color: color.RGBA{255, 255, 255, 255}, Scale: 1.0, Layer: components.LayerWalls})
+	return id
+}
+
+func SpawnWell(w *world.World, pos core.Vector2, radius, mass float64) core.Entity {
+	id := w.CreateEntity()
+	w.Transforms.Add(id, &components.Transform{Position: pos})
+	w.GravityWells.Add(id, &components.GravityWell{Radius: radius, Mass: mass})
+	w.Tags.Add(id, &components.Tag{Name: "gravity_well"})
+	return id
+}
+
+// SpawnDecoy drops a short-lived glowing lure that drifts with gravity; scripts find it by its "decoy" tag
+func SpawnDecoy(w *world.World, pos core.Vector2, lifetime float64) core.Entity {
+	id := w.CreateEntity()
+	w.Transforms.Add(id, &components.Transform{Position: pos})
+	w.Physics.Add(id, &components.Physics{MaxSpeed: 4.0, Friction: 0.9, Mass: 1.0})
+	w.Renders.Add(id, &components.Render{
+		Sprite: w.Gfx.NewSolidSprite(12, 12, color.RGBA{0, 255, 255, 255}),
+		Color:  color.RGBA{255, 255, 255, 160},
+		Glow:   true,
+		Scale:  1.0,
+		Layer:  components.LayerDecoys,
+	})
+	w.Lifetimes.Add(id, &components.Lifetime{TimeRemaining: lifetime})
+	w.Tags.Add(id, &components.Tag{Name: "decoy"})
+	return id
+}
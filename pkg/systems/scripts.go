@@ -0,0 +1,141 @@
+package systems
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// CoreScripts are loaded at startup whether or not an entity references them yet
+var CoreScripts = []string{"runner.lua", "spectre.lua"}
+
+// ScriptDir is where CoreScripts are loaded from; tools running outside the game directory point it elsewhere
+var ScriptDir = "."
+
+// LoadScripts (re)runs the behaviour scripts, discarding any state left over from a previous level
+func LoadScripts(w *world.World, diag *ScriptDiagnostics) error {
+	// Load scripts as modules/tables
+	// We will load them into global tables named after their filename (minus extension)
+	for _, script := range CoreScripts {
+		if err := LoadScript(w, script); err != nil {
+			if serr := diag.Report(newScriptError(script, NoEntity, w.Tick, err)); serr != nil { return serr }
+		}
+	}
+	return nil
+}
+
+// LoadScript runs one script from ScriptDir, defining the table named after it
+func LoadScript(w *world.World, script string) error {
+	return runFile(w.LState, filepath.Join(ScriptDir, script))
+}
+
+// ScriptReload reports the outcome of reloading one changed script
+type ScriptReload struct {
+	Script string
+	Err    error // Non-nil means the previous version is still running
+}
+
+func (r ScriptReload) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s FAILED, KEEPING PREVIOUS VERSION: %v", r.Script, r.Err)
+	}
+	return r.Script + " RELOADED"
+}
+
+// ScriptWatcher polls script modification times so designers can tune AI without restarting
+type ScriptWatcher struct {
+	Dir      string
+	Interval time.Duration // Minimum time between polls; stat calls are cheap but not free every frame
+	mtimes   map[string]time.Time
+	last     time.Time
+}
+
+func NewScriptWatcher(dir string) *ScriptWatcher {
+	return &ScriptWatcher{Dir: dir, Interval: 500 * time.Millisecond, mtimes: make(map[string]time.Time)}
+}
+
+// Poll reloads every watched script whose file changed since it was last seen.
+// Watched scripts are CoreScripts plus anything an AI component refers to.
+func (sw *ScriptWatcher) Poll(w *world.World) []ScriptReload {
+	if time.Since(sw.last) < sw.Interval {
+		return nil
+	}
+	sw.last = time.Now()
+
+	var results []ScriptReload
+	for _, script := range sw.watched(w) {
+		info, err := os.Stat(filepath.Join(sw.Dir, script))
+		if err != nil {
+			continue
+		}
+		prev, seen := sw.mtimes[script]
+		sw.mtimes[script] = info.ModTime()
+		// The first sighting only records a baseline; the script is already loaded
+		if !seen || info.ModTime().Equal(prev) {
+			continue
+		}
+		err = reloadScript(w.LState, filepath.Join(sw.Dir, script), getScriptName(script))
+		results = append(results, ScriptReload{Script: script, Err: err})
+		if err == nil { giveScriptAnotherChance(w, script) }
+	}
+	return results
+}
+
+func (sw *ScriptWatcher) watched(w *world.World) []string {
+	set := make(map[string]bool)
+	for _, s := range CoreScripts {
+		set[s] = true
+	}
+	for _, ai := range w.AIs.All() {
+		if ai.ScriptName != "" {
+			set[ai.ScriptName] = true
+		}
+	}
+	scripts := make([]string, 0, len(set))
+	for s := range set {
+		scripts = append(scripts, s)
+	}
+	sort.Strings(scripts)
+	return scripts
+}
+
+// runFile compiles and runs one script's top-level chunk within the load budget
+func runFile(L *lua.LState, path string) error {
+	fn, err := L.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	return callBudgeted(L, ScriptLoadBudget, 0, fn)
+}
+
+// reloadScript compiles before running so a syntax error never touches the live version,
+// and restores the old module table if the new chunk fails while executing
+func reloadScript(L *lua.LState, path, module string) error {
+	fn, err := L.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	old := L.GetGlobal(module)
+	if err := callBudgeted(L, ScriptLoadBudget, 0, fn); err != nil {
+		L.SetGlobal(module, old)
+		return err
+	}
+	if L.GetGlobal(module).Type() != lua.LTTable {
+		L.SetGlobal(module, old)
+		return fmt.Errorf("script did not define the %s table", module)
+	}
+	return nil
+}
+
+// giveScriptAnotherChance takes entities that fell back to native code off it once their script is fixed
+func giveScriptAnotherChance(w *world.World, script string) {
+	for _, ai := range w.AIs.All() {
+		if ai.ScriptName == script { ai.Failures = 0 }
+	}
+}
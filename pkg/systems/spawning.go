@@ -0,0 +1,143 @@
+package systems
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/particles"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// MaxScriptParticles caps one emit_particles call so a runaway loop cannot flood the pool
+	MaxScriptParticles = 200
+	// MaxScreenShake caps what scripts can stack up; the main loop decays it from there
+	MaxScreenShake = 30.0
+	// MaxWellMass bounds a spawned well either way; far beyond that the pull overflows to infinity
+	MaxWellMass = 1000.0
+)
+
+// prefab builds a named entity for spawn(); opts may be nil when the script passed no table, and
+// bad options raise a Lua error against spawn's fourth argument
+type prefab func(L *lua.LState, w *world.World, pos core.Vector2, opts *lua.LTable) core.Entity
+
+var prefabs = map[string]prefab{
+	"bullet": func(L *lua.LState, w *world.World, pos core.Vector2, opts *lua.LTable) core.Entity {
+		return SpawnBullet(w, pos, core.Vector2{X: optFinite(L, 4, opts, "vx", 8), Y: optFinite(L, 4, opts, "vy", 0)})
+	},
+	"wall": func(L *lua.LState, w *world.World, pos core.Vector2, opts *lua.LTable) core.Entity {
+		return SpawnWall(w, pos, optBool(opts, "destructible"))
+	},
+	"well": func(L *lua.LState, w *world.World, pos core.Vector2, opts *lua.LTable) core.Entity {
+		radius, mass := optPositive(L, 4, opts, "radius", 40), optFinite(L, 4, opts, "mass", 1.5)
+		if math.Abs(mass) > MaxWellMass { L.ArgError(4, fmt.Sprintf("mass must be between -%v and %v, got %v", MaxWellMass, MaxWellMass, mass)) }
+		return SpawnWell(w, pos, radius, mass)
+	},
+	"decoy": func(L *lua.LState, w *world.World, pos core.Vector2, opts *lua.LTable) core.Entity {
+		return SpawnDecoy(w, pos, optPositive(L, 4, opts, "lifetime", 3))
+	},
+}
+
+// registerSpawning lets scripts create and remove entities and drive the screen effects
+func registerSpawning(L *lua.LState, w *world.World) {
+	// spawn(prefab, x, y [, opts]) -> id
+	L.SetGlobal("spawn", L.NewFunction(func(L *lua.LState) int {
+		name := L.CheckString(1)
+		pos := core.Vector2{X: checkFinite(L, 2), Y: checkFinite(L, 3)}
+		opts := L.OptTable(4, nil)
+		build, ok := prefabs[name]
+		if !ok {
+			L.ArgError(1, "unknown prefab "+name)
+		}
+		core.WrapFully(&pos)
+		L.Push(luaHandle(w, build(L, w, pos, opts)))
+		return 1
+	}))
+
+	// destroy(id) -> whether anything was removed. Destroying twice is harmless, since lifetimes
+	// can expire an entity under a script that still holds its ID; a malformed ID is an error.
+	L.SetGlobal("destroy", L.NewFunction(func(L *lua.LState) int {
+		id, ok := w.Resolve(checkHandle(L, w, 1))
+		if !ok || !w.Exists(id) {
+			L.Push(lua.LFalse)
+			return 1
+		}
+		w.DestroyEntity(id)
+		L.Push(lua.LTrue)
+		return 1
+	}))
+
+	// emit_particles(x, y [, opts]) with opts count, speed, quirk, r, g, b, a, decay, vx, vy
+	L.SetGlobal("emit_particles", L.NewFunction(func(L *lua.LState) int {
+		pos := core.Vector2{X: checkFinite(L, 1), Y: checkFinite(L, 2)}
+		opts := L.OptTable(3, nil)
+		quirk, ok := particles.ParseQuirk(optString(opts, "quirk", "standard"))
+		if !ok {
+			L.ArgError(3, "unknown particle quirk "+optString(opts, "quirk", ""))
+		}
+		count := int(math.Max(0, math.Min(optFinite(L, 3, opts, "count", 8), MaxScriptParticles)))
+		speed := optFinite(L, 3, opts, "speed", 3)
+		bias := core.Vector2{X: optFinite(L, 3, opts, "vx", 0), Y: optFinite(L, 3, opts, "vy", 0)}
+		col := color.RGBA{channel(opts, "r"), channel(opts, "g"), channel(opts, "b"), channel(opts, "a")}
+		decay := optFinite(L, 3, opts, "decay", 0)
+
+		// Particles are presentation only, so they draw on the global RNG and leave the sim stream alone
+		for i := 0; i < count; i++ {
+			angle, s := rand.Float64()*2*math.Pi, rand.Float64()*speed
+			vel := core.Vector2{X: math.Cos(angle)*s + bias.X, Y: math.Sin(angle)*s + bias.Y}
+			d := decay
+			if d <= 0 { d = 0.01 + rand.Float64()*0.04 }
+			w.Particles.EmitAdvanced(pos, vel, col, d, quirk)
+		}
+		return 0
+	}))
+
+	// screen_shake(amount) adds to the current shake
+	L.SetGlobal("screen_shake", L.NewFunction(func(L *lua.LState) int {
+		// math.Max passes NaN through, and a NaN shake would never decay
+		amount := math.Max(0, checkFinite(L, 1))
+		w.ScreenShake = math.Min(w.ScreenShake+amount, MaxScreenShake)
+		return 0
+	}))
+}
+
+func optNumber(t *lua.LTable, key string, def float64) float64 {
+	if t == nil { return def }
+	if n, ok := t.RawGetString(key).(lua.LNumber); ok { return float64(n) }
+	return def
+}
+
+// optFinite is optNumber for values that end up in the simulation, raising a Lua error against
+// argument n when the option is NaN or infinite
+func optFinite(L *lua.LState, n int, t *lua.LTable, key string, def float64) float64 {
+	v := optNumber(t, key, def)
+	if math.IsNaN(v) || math.IsInf(v, 0) { L.ArgError(n, fmt.Sprintf("%s must be a finite number, got %v", key, v)) }
+	return v
+}
+
+// optPositive is optFinite for sizes and durations, which must also be above zero
+func optPositive(L *lua.LState, n int, t *lua.LTable, key string, def float64) float64 {
+	v := optFinite(L, n, t, key, def)
+	if v <= 0 { L.ArgError(n, fmt.Sprintf("%s must be above 0, got %v", key, v)) }
+	return v
+}
+
+func optString(t *lua.LTable, key, def string) string {
+	if t == nil { return def }
+	if s, ok := t.RawGetString(key).(lua.LString); ok { return string(s) }
+	return def
+}
+
+func optBool(t *lua.LTable, key string) bool {
+	return t != nil && lua.LVAsBool(t.RawGetString(key))
+}
+
+// channel reads a 0-255 colour component, defaulting to full intensity
+func channel(t *lua.LTable, key string) uint8 {
+	return uint8(math.Max(0, math.Min(255, optNumber(t, key, 255))))
+}
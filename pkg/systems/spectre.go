@@ -3,10 +3,9 @@ package systems
 import (
 	"image/color"
 
+	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
 	"beautifulmess/pkg/world"
-	
-	"github.com/hajimehoshi/ebiten/v2"
 )
 
 // SpectreVisualState tracks the high-level emotional state of the Spectre entity.
@@ -20,11 +19,11 @@ type SpectreVisualState struct {
 // SystemSpectreVisuals manages the emotional reactivity of the Spectre.
 // It bridges the gap between raw physics data (velocity/acceleration) and 
 // narrative-driven visual feedback (the 3 source photos).
-func SystemSpectreVisuals(w *world.World, gState *SpectreVisualState, spectreID core.Entity, sprites map[string]*ebiten.Image) {
-	if int(spectreID) >= len(w.Renders) || w.Renders[spectreID] == nil { return }
-	render := w.Renders[spectreID]
-	trans := w.Transforms[spectreID]
-	phys := w.Physics[spectreID]
+func SystemSpectreVisuals(w *world.World, gState *SpectreVisualState, spectreID core.Entity, sprites map[string]components.Sprite) {
+	render := w.Renders.Get(spectreID)
+	if render == nil { return }
+	trans := w.Transforms.Get(spectreID)
+	phys := w.Physics.Get(spectreID)
 	if trans == nil { return }
 
 	targetState := "normal"
@@ -32,10 +31,8 @@ func SystemSpectreVisuals(w *world.World, gState *SpectreVisualState, spectreID
 	// We prioritize the "Kewt" state as it represents a total loss of agency within a well.
 	// 30px buffer provides a 'gravity-well' event horizon for the visual transition.
 	const kewtRangeSq = 30.0 * 30.0
-	for id, well := range w.GravityWells {
-		if well == nil { continue }
-		wellTrans := w.Transforms[id]
-		if wellTrans == nil { continue }
+	for id := range world.Query(w.GravityWells, w.Transforms) {
+		well, wellTrans := w.GravityWells.Get(id), w.Transforms.Get(id)
 		
 		// Squared distance avoids the computationally expensive math.Sqrt in the hot update path.
 		if core.DistSqWrapped(trans.Position, wellTrans.Position) < (well.Radius*well.Radius + kewtRangeSq) {
@@ -67,7 +64,7 @@ func SystemSpectreVisuals(w *world.World, gState *SpectreVisualState, spectreID
 	// Sprite selection is now stable thanks to the uniform 128x128 resolution forced at load-time.
 	render.Sprite = sprites[gState.State]
 	
-	specW, _ := render.Sprite.Size()
+	specW := render.Sprite.Bounds().Dx()
 	baseScale := 80.0 / float64(specW)
 	targetScale := baseScale
 	targetColor := color.RGBA{255, 255, 255, 255}
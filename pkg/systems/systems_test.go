@@ -0,0 +1,1230 @@
+package systems
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"beautifulmess/pkg/bt"
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/particles"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// stubInput holds a fixed direction so input-driven systems can run without a keyboard
+type stubInput struct {
+	dir   core.Vector2
+	boost bool
+}
+
+func (s stubInput) MoveDir() core.Vector2 { return s.dir }
+func (s stubInput) Boost() bool           { return s.boost }
+
+func spawnBody(w *world.World, tag string, pos, vel core.Vector2) core.Entity {
+	id := w.CreateEntity()
+	w.Tags.Add(id, &components.Tag{Name: tag})
+	w.Transforms.Add(id, &components.Transform{Position: pos})
+	w.Physics.Add(id, &components.Physics{Velocity: vel, MaxSpeed: 20, Friction: 1, Mass: 1})
+	return id
+}
+
+// chaseFixture is the chase the spectre brain tests share: a runner, the spectre after it under ai,
+// a wall beside the spectre and a well off to one side. src is run first to define the script;
+// shippedSpectre(tb) is the real one.
+func chaseFixture(tb testing.TB, src string, ai *components.AI) (*world.World, core.Entity) {
+	tb.Helper()
+	dir := TreeDir
+	TreeDir = "../../behaviours"
+	tb.Cleanup(func() { TreeDir = dir })
+
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(src); err != nil {
+		tb.Fatal(err)
+	}
+	runner := spawnBody(w, "runner", core.Vector2{X: 300, Y: 300}, core.Vector2{})
+	spectre := spawnBody(w, "spectre", core.Vector2{X: 400, Y: 300}, core.Vector2{})
+	ai.ScriptName, ai.TargetID = "spectre.lua", w.Handle(runner)
+	w.AIs.Add(spectre, ai)
+	SpawnWall(w, core.Vector2{X: 450, Y: 300}, false)
+	SpawnWell(w, core.Vector2{X: 800, Y: 600}, 40, 1.5)
+	return w, spectre
+}
+
+func shippedSpectre(tb testing.TB) string {
+	tb.Helper()
+	src, err := os.ReadFile("../../spectre.lua")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return string(src)
+}
+
+// luaID is how a script refers to id
+func luaID(w *world.World, id core.Entity) string {
+	return fmt.Sprint(w.Handle(id).Pack())
+}
+
+func TestSystemPhysicsWrapsPosition(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  core.Vector2
+		vel  core.Vector2
+		want core.Vector2
+	}{
+		{"Right edge", core.Vector2{X: core.ScreenWidth - 1, Y: 100}, core.Vector2{X: 3}, core.Vector2{X: 2, Y: 100}},
+		{"Top edge", core.Vector2{X: 100, Y: 1}, core.Vector2{Y: -3}, core.Vector2{X: 100, Y: core.ScreenHeight - 2}},
+		{"Interior", core.Vector2{X: 100, Y: 100}, core.Vector2{X: 5, Y: 5}, core.Vector2{X: 105, Y: 105}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			id := spawnBody(w, "runner", tt.pos, tt.vel)
+			SystemPhysics(w, false, false)
+			if got := w.Transforms.Get(id).Position; got != tt.want {
+				t.Errorf("position = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemLifetimeDestroysExpired(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	id := spawnBody(w, "bullet", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.Lifetimes.Add(id, &components.Lifetime{TimeRemaining: 0.02})
+
+	SystemLifetime(w)
+	if w.Transforms.Get(id) == nil {
+		t.Fatal("entity destroyed before its lifetime ran out")
+	}
+	SystemLifetime(w)
+	if w.Transforms.Get(id) != nil {
+		t.Error("entity survived past its lifetime")
+	}
+}
+
+func TestSystemProjectileEmitterSpawnsBullet(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	id := spawnBody(w, "runner", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.ProjectileEmitters.Add(id, &components.ProjectileEmitter{Interval: 1.0})
+
+	w.Advance()
+	SystemProjectileEmitter(w)
+
+	bullets := 0
+	for _, tag := range w.Tags.All() {
+		if tag != nil && tag.Name == "bullet" {
+			bullets++
+		}
+	}
+	if bullets != 1 {
+		t.Fatalf("emitter spawned %d bullets, want 1", bullets)
+	}
+	if w.Physics.Get(id).Velocity.X >= 0 {
+		t.Error("firing did not apply recoil")
+	}
+}
+
+func TestSystemProjectileEmitterCadence(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval float64
+		ticks    int
+		want     int
+	}{
+		{"Fires on first tick", 1.0, 1, 1},
+		{"Waits a full interval", 1.0, 60, 1},
+		{"Fires again after interval", 1.0, 61, 2},
+		{"Half second cadence", 0.5, 181, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			id := spawnBody(w, "runner", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+			w.ProjectileEmitters.Add(id, &components.ProjectileEmitter{Interval: tt.interval})
+
+			shots := 0
+			for i := 0; i < tt.ticks; i++ {
+				w.Advance()
+				before := w.ProjectileEmitters.Get(id).LastTime
+				SystemProjectileEmitter(w)
+				if w.ProjectileEmitters.Get(id).LastTime != before {
+					shots++
+				}
+			}
+			if shots != tt.want {
+				t.Errorf("fired %d times in %d ticks, want %d", shots, tt.ticks, tt.want)
+			}
+		})
+	}
+}
+
+// firingRange is a ship spinning on the spot with a fast gun, bouncing bullets off a row of walls
+func firingRange() (*world.World, core.Entity) {
+	w := world.NewHeadlessWorld()
+	ship := spawnBody(w, "runner", core.Vector2{X: 640, Y: 360}, core.Vector2{})
+	w.ProjectileEmitters.Add(ship, &components.ProjectileEmitter{Interval: 0.05})
+	for i := 0; i < 40; i++ {
+		SpawnWall(w, core.Vector2{X: 400 + float64(i)*12, Y: 250}, false)
+	}
+	return w, ship
+}
+
+func fireTick(w *world.World, ship core.Entity) {
+	w.Advance()
+	w.UpdateGrid()
+	w.Transforms.Get(ship).Rotation += 0.1
+	SystemPhysics(w, false, false)
+	SystemProjectileEmitter(w)
+	SystemLifetime(w)
+	w.Particles.Update()
+}
+
+func TestContinuousFireReusesSlots(t *testing.T) {
+	w, ship := firingRange()
+	ticks := int(world.TicksFor(10 * 60))
+	for i := 0; i < ticks; i++ {
+		fireTick(w, ship)
+	}
+	// 20 shots a second living two seconds each, against the 12000 fired
+	if n := w.Slots(); n > 100 {
+		t.Errorf("world grew to %d slots over ten minutes of firing", n)
+	}
+	if n := len(w.ActiveEntities); n > 100 {
+		t.Errorf("%d active entities, want at most the bullets in flight", n)
+	}
+}
+
+// BenchmarkContinuousFire plays a long session one tick per op; slots and live-heap stay flat
+// however large b.N grows
+func BenchmarkContinuousFire(b *testing.B) {
+	w, ship := firingRange()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fireTick(w, ship)
+	}
+	b.StopTimer()
+
+	var mem runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&mem)
+	b.ReportMetric(float64(w.Slots()), "slots")
+	b.ReportMetric(float64(mem.HeapAlloc)/1024, "live-heap-KiB")
+}
+
+func TestBulletHitMarksSpectre(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	spectre := spawnBody(w, "spectre", core.Vector2{X: 300, Y: 300}, core.Vector2{})
+	w.Physics.Get(spectre).GravityMultiplier = 1.0
+	w.Renders.Add(spectre, &components.Render{Sprite: w.Gfx.NewSolidSprite(16, 16, color.RGBA{255, 255, 255, 255})})
+	bullet := spawnBody(w, "bullet", core.Vector2{X: 290, Y: 300}, core.Vector2{X: 5})
+
+	SystemPhysics(w, false, false)
+
+	if got := w.Physics.Get(spectre).GravityMultiplier; got != 2.0 {
+		t.Errorf("GravityMultiplier = %v, want 2", got)
+	}
+	if w.Transforms.Get(bullet) != nil {
+		t.Error("bullet survived the hit")
+	}
+}
+
+func TestCollisionsPublishEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		spawn     func(w *world.World) // Puts something in the way of a bullet fired from (290, 300)
+		want      string
+		wantShake float64
+	}{
+		{"Spectre", func(w *world.World) {
+			spectre := spawnBody(w, "spectre", core.Vector2{X: 300, Y: 300}, core.Vector2{})
+			w.Renders.Add(spectre, &components.Render{Color: color.RGBA{255, 255, 255, 255}})
+		}, "hit", 8},
+		{"Destructible wall", func(w *world.World) { SpawnWall(w, core.Vector2{X: 300, Y: 300}, true) }, "shattered", 4},
+		{"Solid wall", func(w *world.World) { SpawnWall(w, core.Vector2{X: 300, Y: 300}, false) }, "bounced", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			SubscribeFeedback(w)
+			var got []string
+			world.Subscribe(w, func(world.SpectreHit) { got = append(got, "hit") })
+			world.Subscribe(w, func(e world.WallShattered) {
+				got = append(got, "shattered")
+				if w.Alive(e.Wall) {
+					t.Error("wall still alive when WallShattered was handled")
+				}
+			})
+			world.Subscribe(w, func(world.BulletBounced) { got = append(got, "bounced") })
+			tt.spawn(w)
+			spawnBody(w, "bullet", core.Vector2{X: 290, Y: 300}, core.Vector2{X: 5})
+			w.UpdateGrid()
+
+			SystemPhysics(w, false, false)
+			if len(got) != 0 || w.ScreenShake != 0 {
+				t.Fatalf("handled %v before Dispatch", got)
+			}
+			w.Dispatch()
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("events = %v, want [%s]", got, tt.want)
+			}
+			if w.ScreenShake != tt.wantShake {
+				t.Errorf("ScreenShake = %v, want %v", w.ScreenShake, tt.wantShake)
+			}
+		})
+	}
+}
+
+func TestScriptsHearEvents(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		listener = {}
+		function listener.init(self, id) self.heard = {} end
+		function listener.on_event(self, id, ev)
+			table.insert(self.heard, ev.name .. " " .. ev.x .. " " .. tostring(ev.bullet))
+		end
+		function listener.update_state(self, id) heard = table.concat(self.heard, ",") end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	id := spawnBody(w, "spectre", core.Vector2{X: 300, Y: 300}, core.Vector2{})
+	w.AIs.Add(id, &components.AI{ScriptName: "listener.lua"})
+	bullet := spawnBody(w, "bullet", core.Vector2{X: 290, Y: 300}, core.Vector2{X: 5})
+	bulletID := luaID(w, bullet)
+
+	SystemPhysics(w, false, false)
+	w.Dispatch()
+	if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.LState.GetGlobal("heard").String(), "spectre_hit 300 "+bulletID; got != want {
+		t.Errorf("on_event heard %q, want %q", got, want)
+	}
+
+	// Each event is delivered once
+	if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true)); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.LState.GetGlobal("heard").String(); strings.Count(got, "spectre_hit") != 1 {
+		t.Errorf("on_event heard %q after a quiet tick, want the hit once", got)
+	}
+}
+
+func TestSystemInputUsesBackend(t *testing.T) {
+	w := world.NewWorld(world.NopAudio{}, world.NopGraphics{}, stubInput{dir: core.Vector2{X: 1}})
+	id := spawnBody(w, "runner", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.InputControlleds.Add(id, &components.InputControlled{})
+
+	SystemInput(w)
+	if got := w.Physics.Get(id).Acceleration.X; got <= 0 {
+		t.Errorf("Acceleration.X = %v, want positive", got)
+	}
+}
+
+func TestSystemAIRunsScript(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		pusher = {}
+		function pusher.update_state(self, id) apply_force(id, 2, -1) end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.AIs.Add(id, &components.AI{ScriptName: "pusher.lua"})
+
+	SystemAI(w, &level.Level{}, nil)
+	if got := w.Physics.Get(id).Acceleration; got != (core.Vector2{X: 2, Y: -1}) {
+		t.Errorf("Acceleration = %+v, want {2 -1}", got)
+	}
+}
+
+func TestScriptStateIsPerEntity(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		counter = {}
+		function counter.init(self, id) self.n = id * 10 end
+		function counter.update_state(self, id)
+			self.n = self.n + 1
+			apply_force(id, self.n, 0)
+		end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	a := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	b := spawnBody(w, "spectre", core.Vector2{X: 200, Y: 200}, core.Vector2{})
+	w.AIs.Add(a, &components.AI{ScriptName: "counter.lua"})
+	w.AIs.Add(b, &components.AI{ScriptName: "counter.lua"})
+
+	for i := 0; i < 3; i++ {
+		SystemAI(w, &level.Level{}, nil)
+	}
+	// Each entity counts its own updates from its own starting point
+	start := func(id core.Entity) float64 { return float64(w.Handle(id).Pack()) * 10 }
+	if got, want := w.Physics.Get(a).Acceleration.X, 3*start(a)+1+2+3; got != want {
+		t.Errorf("entity a acceleration = %v, want %v", got, want)
+	}
+	if got, want := w.Physics.Get(b).Acceleration.X, 3*start(b)+1+2+3; got != want {
+		t.Errorf("entity b acceleration = %v, want %v", got, want)
+	}
+
+	w.DestroyEntity(b)
+	c := spawnBody(w, "spectre", core.Vector2{X: 300, Y: 300}, core.Vector2{})
+	w.AIs.Add(c, &components.AI{ScriptName: "counter.lua"})
+	SystemAI(w, &level.Level{}, nil)
+	if got := w.Physics.Get(c).Acceleration.X; got != start(c)+1 {
+		t.Errorf("new entity acceleration = %v, want fresh state", got)
+	}
+}
+
+func TestScriptWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pusher.lua")
+	write := func(src string, age time.Duration) {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Explicit mtimes keep the test independent of filesystem timestamp resolution
+		stamp := time.Now().Add(age)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pushX := func(x int) string {
+		return fmt.Sprintf("pusher = {}\nfunction pusher.update_state(self, id) apply_force(id, %d, 0) end", x)
+	}
+
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	write(pushX(1), -time.Hour)
+	if err := w.LState.DoFile(path); err != nil {
+		t.Fatal(err)
+	}
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.AIs.Add(id, &components.AI{ScriptName: "pusher.lua"})
+
+	sw := NewScriptWatcher(dir)
+	sw.Interval = 0
+	if got := sw.Poll(w); len(got) != 0 {
+		t.Fatalf("first poll reloaded %v, want a baseline only", got)
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+		wantX   float64
+	}{
+		{"Valid edit", pushX(5), false, 5},
+		{"Syntax error keeps previous", "pusher = {", true, 5},
+		{"Runtime error keeps previous", "pusher = {}\nerror('boom')", true, 5},
+		{"Recovers after fix", pushX(7), false, 7},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.src, time.Duration(i)*time.Minute)
+			got := sw.Poll(w)
+			if len(got) != 1 || got[0].Script != "pusher.lua" {
+				t.Fatalf("Poll() = %v, want one pusher.lua reload", got)
+			}
+			if (got[0].Err != nil) != tt.wantErr {
+				t.Errorf("reload error = %v, wantErr %v", got[0].Err, tt.wantErr)
+			}
+			w.Physics.Get(id).Acceleration = core.Vector2{}
+			SystemAI(w, &level.Level{}, nil)
+			if x := w.Physics.Get(id).Acceleration.X; x != tt.wantX {
+				t.Errorf("script pushed %v, want %v", x, tt.wantX)
+			}
+		})
+	}
+}
+
+func TestScriptErrorsAreReported(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		strict     bool
+		wantErr    bool
+		wantCount  int
+		wantInText string
+	}{
+		{"Runtime error is collected", "broken = {}\nfunction broken.update_state(self, id) local x = nil; x.y = 1 end", false, false, 3, "broken.lua (entity 0)"},
+		{"Strict mode aborts", "broken = {}\nfunction broken.update_state(self, id) error('kaput') end", true, true, 1, "kaput"},
+		{"Init failure", "broken = {}\nfunction broken.init(self, id) error('bad init') end\nfunction broken.update_state(self, id) end", false, false, 1, "bad init"},
+		{"Missing table", "", false, false, 3, "not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			InitLua(w)
+			if err := w.LState.DoString(tt.src); err != nil {
+				t.Fatal(err)
+			}
+			id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+			w.AIs.Add(id, &components.AI{ScriptName: "broken.lua"})
+
+			diag := NewScriptDiagnostics(tt.strict)
+			var err error
+			for i := 0; i < 3 && err == nil; i++ {
+				err = SystemAI(w, &level.Level{}, diag)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SystemAI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(diag.Recent) != 1 {
+				t.Fatalf("collected %d distinct errors, want 1", len(diag.Recent))
+			}
+			got := diag.Recent[0]
+			if got.Count != tt.wantCount {
+				t.Errorf("Count = %d, want %d", got.Count, tt.wantCount)
+			}
+			if got.Entity != id {
+				t.Errorf("Entity = %d, want %d", got.Entity, id)
+			}
+			if !strings.Contains(got.Error(), tt.wantInText) {
+				t.Errorf("error %q does not mention %q", got.Error(), tt.wantInText)
+			}
+		})
+	}
+}
+
+func TestPerceptionBindings(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	wall := w.CreateEntity()
+	w.Transforms.Add(wall, &components.Transform{Position: core.Vector2{X: 300, Y: 100}})
+	w.Walls.Add(wall, &components.Wall{Size: 10, Destructible: true})
+	well := w.CreateEntity()
+	w.Transforms.Add(well, &components.Transform{Position: core.Vector2{X: 640, Y: 360}})
+	w.GravityWells.Add(well, &components.GravityWell{Mass: 5000, Radius: 40})
+	bullet := spawnBody(w, "bullet", core.Vector2{X: 110, Y: 100}, core.Vector2{})
+	w.UpdateGrid()
+	handle := func(id core.Entity) float64 { return float64(w.Handle(id).Pack()) }
+
+	tests := []struct {
+		name string
+		expr string
+		want float64
+	}{
+		{"Walls near count", "#get_walls_near(290, 100, 20)", 1},
+		{"Walls near destructible", "get_walls_near(290, 100, 20)[1].destructible and 1 or 0", 1},
+		{"Walls out of range", "#get_walls_near(100, 600, 20)", 0},
+		{"Well mass", "get_wells()[1].mass", 5000},
+		{"Well id", "get_wells()[1].id", handle(well)},
+		{"Walls near id", "get_walls_near(290, 100, 20)[1].id", handle(wall)},
+		{"Raycast wall id", "select(5, raycast(100, 100, 1, 0, 500))", handle(wall)},
+		{"Raycast miss", "raycast(100, 100, 0, 1, 50) and 1 or 0", 0},
+		{"Find by tag", "#find_by_tag('bullet', 100, 100, 20)", 1},
+		{"Find by tag id", "find_by_tag('bullet', 100, 100, 20)[1].id", handle(bullet)},
+		{"Find by other tag", "#find_by_tag('wall', 100, 100, 20)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := w.LState.DoString("result = " + tt.expr); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := w.LState.GetGlobal("result").(lua.LNumber)
+			if !ok || float64(got) != tt.want {
+				t.Errorf("%s = %v, want %v", tt.expr, w.LState.GetGlobal("result"), tt.want)
+			}
+		})
+	}
+}
+
+func TestPerceptionRejectsBadNumbers(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	tests := []struct {
+		name    string
+		call    string
+		wantErr bool
+	}{
+		{"Raycast", "raycast(100, 100, 1, 0, 500)", false},
+		{"Raycast far past the cap", "raycast(100, 100, 1, 0, 1e15)", false},
+		{"Raycast negative", "raycast(100, 100, 1, 0, -1)", true},
+		{"Raycast NaN", "raycast(100, 100, 1, 0, 0/0)", true},
+		{"Raycast infinite", "raycast(100, 100, 1, 0, 1/0)", true},
+		{"Trace shot", "trace_shot(100, 100, 1, 0, 300, 100, 500, 2)", false},
+		{"Trace shot far past the cap", "trace_shot(100, 100, 1, 0, 300, 100, 1e15)", false},
+		{"Trace shot NaN", "trace_shot(100, 100, 1, 0, 300, 100, 0/0)", true},
+		{"Trace shot negative bounces", "trace_shot(100, 100, 1, 0, 300, 100, 500, -1)", true},
+		{"Raycast NaN origin", "raycast(0/0, 0, 1, 0, 10)", true},
+		{"Raycast infinite direction", "raycast(100, 100, 1/0, 0, 10)", true},
+		{"Trace shot NaN origin", "trace_shot(0/0, 100, 1, 0, 300, 100, 500)", true},
+		{"Trace shot NaN target", "trace_shot(100, 100, 1, 0, 300, 0/0, 500)", true},
+		{"Walls near", "get_walls_near(100, 100, 50)", false},
+		{"Walls near NaN radius", "get_walls_near(100, 100, 0/0)", true},
+		{"Walls near NaN point", "get_walls_near(0/0, 100, 50)", true},
+		{"Find by tag NaN radius", "find_by_tag('decoy', 100, 100, 0/0)", true},
+		{"Find by tag negative radius", "find_by_tag('decoy', 100, 100, -5)", true},
+		{"Vector between infinities", "get_vec_between(1/0, 0, 0, 0)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.LState.DoString(tt.call)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("%s error = %v, wantErr %v", tt.call, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpawnBindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantTag string
+		wantErr bool
+	}{
+		{"Bullet", "id = spawn('bullet', 100, 100, {vx = 0, vy = 8})", "bullet", false},
+		{"Wall", "id = spawn('wall', 100, 100, {destructible = true})", "", false},
+		{"Well", "id = spawn('well', 100, 100, {radius = 60, mass = 2})", "gravity_well", false},
+		{"Decoy", "id = spawn('decoy', 100, 100)", "decoy", false},
+		{"Unknown prefab", "id = spawn('dragon', 100, 100)", "", true},
+		{"Far off screen", "id = spawn('decoy', 1e12, -1e12)", "decoy", false},
+		{"NaN position", "id = spawn('decoy', 0/0, 0)", "", true},
+		{"Infinite position", "id = spawn('wall', 0, (1/0))", "", true},
+		{"NaN velocity", "id = spawn('bullet', 100, 100, {vx = 0/0})", "", true},
+		{"Infinite velocity", "id = spawn('bullet', 100, 100, {vy = -(1/0)})", "", true},
+		{"Negative radius", "id = spawn('well', 100, 100, {radius = -40})", "", true},
+		{"Zero radius", "id = spawn('well', 100, 100, {radius = 0})", "", true},
+		{"NaN mass", "id = spawn('well', 100, 100, {mass = 0/0})", "", true},
+		{"Huge mass", "id = spawn('well', 100, 100, {mass = 1e307})", "", true},
+		{"Repelling well", "id = spawn('well', 100, 100, {mass = -1})", "gravity_well", false},
+		{"Zero lifetime", "id = spawn('decoy', 100, 100, {lifetime = 0})", "", true},
+		{"Infinite lifetime", "id = spawn('decoy', 100, 100, {lifetime = (1/0)})", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			InitLua(w)
+			err := w.LState.DoString(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("spawn error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if n := w.Transforms.Len(); n != 0 {
+					t.Errorf("rejected spawn left %d entities behind", n)
+				}
+				return
+			}
+			id, ok := w.Resolve(core.UnpackHandle(int64(lua.LVAsNumber(w.LState.GetGlobal("id")))))
+			if !ok || !w.Exists(id) {
+				t.Fatalf("spawned entity %d does not exist", id)
+			}
+			if tag := w.Tags.Get(id); tt.wantTag != "" && (tag == nil || tag.Name != tt.wantTag) {
+				t.Errorf("tag = %+v, want %q", tag, tt.wantTag)
+			}
+			if p := w.Transforms.Get(id).Position; p.X < 0 || p.X >= core.ScreenWidth || p.Y < 0 || p.Y >= core.ScreenHeight {
+				t.Errorf("spawned at %+v, want a position on screen", p)
+			}
+			SystemPhysics(w, false, false)
+		})
+	}
+}
+
+func TestDestroyBinding(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	id := spawnBody(w, "decoy", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+
+	tests := []struct {
+		name    string
+		arg     string
+		want    bool
+		wantErr bool
+	}{
+		{"Live entity", luaID(w, id), true, false},
+		{"Already destroyed", luaID(w, id), false, false},
+		{"Negative", "-1", false, true},
+		{"Out of range", "9999", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.LState.DoString("ok = destroy(" + tt.arg + ")")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("destroy(%s) error = %v, wantErr %v", tt.arg, err, tt.wantErr)
+			}
+			if tt.wantErr { return }
+			if got := lua.LVAsBool(w.LState.GetGlobal("ok")); got != tt.want {
+				t.Errorf("destroy(%s) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectBindings(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		emit_particles(100, 100, {count = 5000, quirk = "orbit", r = 255, g = 0, b = 0})
+		screen_shake(100)
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(w.Particles.Particles()); n != MaxScriptParticles {
+		t.Errorf("emitted %d particles, want the cap of %d", n, MaxScriptParticles)
+	}
+	if q := w.Particles.Particles()[0].Quirk; q != particles.QuirkOrbit {
+		t.Errorf("quirk = %v, want orbit", q)
+	}
+	if w.ScreenShake != MaxScreenShake {
+		t.Errorf("ScreenShake = %v, want %v", w.ScreenShake, MaxScreenShake)
+	}
+	for _, src := range []string{
+		`emit_particles(0, 0, {quirk = "sparkle"})`,
+		`emit_particles(0/0, 0)`,
+		`emit_particles(0, 0, {speed = (1/0)})`,
+		`screen_shake(0/0)`,
+	} {
+		if err := w.LState.DoString(src); err == nil {
+			t.Errorf("%s did not raise an error", src)
+		}
+	}
+	if w.ScreenShake != MaxScreenShake {
+		t.Errorf("after the rejected calls ScreenShake = %v, want %v", w.ScreenShake, MaxScreenShake)
+	}
+}
+
+func TestSandboxBlocksUnsafeLibraries(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"type(os)", "nil"},
+		{"type(io)", "nil"},
+		{"type(debug)", "nil"},
+		{"type(require)", "nil"},
+		{"type(dofile)", "nil"},
+		{"type(loadstring)", "nil"},
+		{"type(string.format)", "function"},
+		{"type(table.insert)", "function"},
+		{"type(coroutine.yield)", "function"},
+		{"type(math.random)", "function"},
+		// One call is one instruction, so these must be bounded where the budget cannot see them
+		{`(pcall(string.rep, "x", 1e12))`, "false"},
+		{`(pcall(function() return ("x"):rep(1e12) end))`, "false"},
+		{`#string.rep("ab", 3)`, "6"},
+		{`(pcall(string.format, "%999999d", 1))`, "false"},
+		{`(pcall(string.format, "%.999999f", 1))`, "false"},
+		{`string.format("%-5.2f|%%|%03d", 1, 7)`, "1.00 |%|007"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if err := w.LState.DoString("result = " + tt.expr); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.LState.GetGlobal("result").String(); got != tt.want {
+				t.Errorf("%s = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptBudgetSkipsRunawayScripts(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	stuck := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	fine := spawnBody(w, "spectre", core.Vector2{X: 200, Y: 200}, core.Vector2{})
+	if err := w.LState.DoString(fmt.Sprintf(`
+		looper = {}
+		function looper.update_state(self, id)
+			if id == %s then while true do end end
+			apply_force(id, 1, 0)
+		end
+	`, luaID(w, stuck))); err != nil {
+		t.Fatal(err)
+	}
+	w.AIs.Add(stuck, &components.AI{ScriptName: "looper.lua"})
+	w.AIs.Add(fine, &components.AI{ScriptName: "looper.lua"})
+
+	diag := NewScriptDiagnostics(false)
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		SystemAI(w, &level.Level{}, diag)
+	}
+	if elapsed := time.Since(start); elapsed > ScriptWatchdog {
+		t.Errorf("two ticks took %v; the budget did not stop the loop", elapsed)
+	}
+	if len(diag.Recent) != 1 || !strings.Contains(diag.Recent[0].Message, "budget of") {
+		t.Fatalf("Recent = %+v, want one budget error", diag.Recent)
+	}
+	if diag.Recent[0].Entity != stuck || diag.Recent[0].Count != 2 {
+		t.Errorf("budget error = %+v, want entity %d twice", diag.Recent[0], stuck)
+	}
+	// The VM must stay usable for everyone else after a cut-off
+	if x := w.Physics.Get(fine).Acceleration.X; x != 2 {
+		t.Errorf("healthy entity acceleration = %v, want 2", x)
+	}
+}
+
+func TestScriptBudgetIsDeterministic(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`function spin() n = 0 while true do n = n + 1 end end`); err != nil {
+		t.Fatal(err)
+	}
+	// However busy the machine, a runaway script is cut off after the same amount of work
+	var counts []float64
+	for i := 0; i < 3; i++ {
+		if err := callBudgeted(w.LState, ScriptBudget, 0, w.LState.GetGlobal("spin")); err == nil {
+			t.Fatal("spin() returned without hitting the budget")
+		}
+		counts = append(counts, float64(lua.LVAsNumber(w.LState.GetGlobal("n"))))
+	}
+	if counts[0] == 0 || counts[0] != counts[1] || counts[1] != counts[2] {
+		t.Errorf("loop counts when cut off = %v, want the same nonzero count every time", counts)
+	}
+}
+
+func TestBindingsRejectBadIDs(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	live := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	dead := spawnBody(w, "bullet", core.Vector2{X: 200, Y: 200}, core.Vector2{})
+	deadID := w.Handle(dead).Pack()
+	w.DestroyEntity(dead)
+
+	if err := w.LState.DoString(`tree = bt_new({type = "action", fn = "ok"}, {ok = function() end})`); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := map[string]string{
+		"apply_force":          "apply_force(%s, 1, 1)",
+		"set_max_speed":        "set_max_speed(%s, 3)",
+		"rotate":               "rotate(%s, 0.5)",
+		"set_rotation":         "set_rotation(%s, 0.5)",
+		"is_player_controlled": "is_player_controlled(%s)",
+		"get_self":             "get_self(%s)",
+		"get_vec_to":           "get_vec_to(%s, 10, 10)",
+		"get_target":           "get_target(%s)",
+		"get_personality":      "get_personality(%s)",
+		"bt_tick":              "bt_tick(tree, {}, %s)",
+		"destroy":              "destroy(%s)",
+	}
+	ids := []struct {
+		name    string
+		arg     string // Empty for a freshly spawned entity, since destroy uses it up
+		wantErr bool
+	}{
+		{"Live", "", false},
+		{"Destroyed", fmt.Sprint(deadID), true},
+		{"Raw index", fmt.Sprint(live), true},
+		{"Negative", "-1", true},
+		{"Out of range", "100000", true},
+		{"Fractional", "0.5", true},
+		{"Huge", "1e300", true},
+		{"NaN", "0/0", true},
+		{"Nil", "nil", true},
+		{"String", "'spectre'", true},
+	}
+
+	for binding, call := range calls {
+		for _, tt := range ids {
+			t.Run(binding+"/"+tt.name, func(t *testing.T) {
+				arg, wantErr := tt.arg, tt.wantErr
+				if arg == "" {
+					arg = luaID(w, spawnBody(w, "spectre", core.Vector2{X: 300, Y: 300}, core.Vector2{}))
+				}
+				// Lifetimes can expire an entity under a script, so destroying it again is not an error
+				if binding == "destroy" && tt.name == "Destroyed" {
+					wantErr = false
+				}
+				err := w.LState.DoString(fmt.Sprintf(call, arg))
+				if (err != nil) != wantErr {
+					t.Errorf("%s error = %v, wantErr %v", fmt.Sprintf(call, arg), err, wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestBindingsRejectNonFiniteNumbers(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	tests := []struct {
+		call    string
+		wantErr bool
+	}{
+		{"apply_force(%s, 0/0, 0)", true},
+		{"apply_force(%s, 0, -1/0)", true},
+		{"apply_force(%s, 1e308, 0); apply_force(%s, 1e308, 0)", true},
+		{"apply_force(%s, 1e6, 0)", false},
+		{"set_max_speed(%s, 0/0)", true},
+		{"set_max_speed(%s, -1)", true},
+		{"set_max_speed(%s, 1e9)", true},
+		{"set_max_speed(%s, 0)", false},
+		{"rotate(%s, 1/0)", true},
+		{"rotate(%s, 1e308); rotate(%s, 1e308)", false},
+		{"set_rotation(%s, 0/0)", true},
+		{"get_vec_to(%s, 0/0, 10)", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			arg := luaID(w, id)
+			src := strings.ReplaceAll(tt.call, "%s", arg)
+			if err := w.LState.DoString(src); (err != nil) != tt.wantErr {
+				t.Errorf("%s error = %v, wantErr %v", src, err, tt.wantErr)
+			}
+			w.Physics.Get(id).Acceleration = core.Vector2{}
+		})
+	}
+	if r := w.Transforms.Get(id).Rotation; math.Abs(r) > math.Pi {
+		t.Errorf("Rotation = %v, want it kept within a turn", r)
+	}
+}
+
+func TestNaNArgumentIsAScriptError(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		nanny = {}
+		function nanny.update_state(self, id) apply_force(id, 0/0, 0) end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{X: 2, Y: 0})
+	w.AIs.Add(id, &components.AI{ScriptName: "nanny.lua"})
+
+	err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true))
+	var se *ScriptError
+	if !errors.As(err, &se) || !strings.Contains(se.Message, "finite") {
+		t.Fatalf("SystemAI() error = %v, want a script error about a finite number", err)
+	}
+	// The rejected push never reached the body, so the physics carries on as usual
+	SystemPhysics(w, false, false)
+	if p := w.Transforms.Get(id).Position; p != (core.Vector2{X: 102, Y: 100}) {
+		t.Errorf("Position = %+v, want {102 100}", p)
+	}
+}
+
+func TestGetTargetToleratesStaleTarget(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	gone := spawnBody(w, "runner", core.Vector2{X: 200, Y: 200}, core.Vector2{})
+	goneTarget := w.Handle(gone)
+	w.DestroyEntity(gone)
+
+	for _, target := range []core.Handle{{}, {ID: -5, Gen: 1}, {ID: 4000, Gen: 1}, goneTarget} {
+		w.AIs.Add(id, &components.AI{TargetID: target})
+		if err := w.LState.DoString(fmt.Sprintf("n = select('#', get_target(%s))", luaID(w, id))); err != nil {
+			t.Fatalf("TargetID %+v: %v", target, err)
+		}
+		if n := lua.LVAsNumber(w.LState.GetGlobal("n")); n != 0 {
+			t.Errorf("TargetID %+v returned %v values, want none", target, n)
+		}
+	}
+}
+
+func TestBehaviourCoroutines(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"Wait", "mark() wait(0.5) mark() wait() mark()", "0,30,31"},
+		{"Wait until", "wait_until(function() return get_time() >= 0.2 end) mark()", "12"},
+		{"Wait until result", "mark(wait_until(function() return false end, 0.1) and 1 or 0) mark(wait_until(function() return true end, 1) and 1 or 0)", "0,1"},
+		{"Every", "local n = 0 every(0.25, function() mark() n = n + 1 return n < 3 end) mark()", "0,15,30,30"},
+		{"Returns", "mark() return", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			InitLua(w)
+			src := `
+				marks = {}
+				function mark(v) table.insert(marks, v or math.floor(get_time() * 60 + 0.5)) end
+				seq = {}
+				function seq.behave(self, id) ` + tt.body + ` end`
+			if err := w.LState.DoString(src); err != nil {
+				t.Fatal(err)
+			}
+			id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+			w.AIs.Add(id, &components.AI{ScriptName: "seq.lua"})
+
+			for i := 0; i < 60; i++ {
+				if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true)); err != nil {
+					t.Fatal(err)
+				}
+				w.Advance()
+			}
+			if err := w.LState.DoString("result = table.concat(marks, ',')"); err != nil {
+				t.Fatal(err)
+			}
+			if got := w.LState.GetGlobal("result").String(); got != tt.want {
+				t.Errorf("marks = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitOutsideBehaviourFails(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	if err := w.LState.DoString(`
+		eager = {}
+		function eager.update_state(self, id) wait(1) end
+	`); err != nil {
+		t.Fatal(err)
+	}
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	w.AIs.Add(id, &components.AI{ScriptName: "eager.lua"})
+
+	err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true))
+	if err == nil || !strings.Contains(err.Error(), "behave coroutine") {
+		t.Errorf("SystemAI() error = %v, want a behave-only error", err)
+	}
+}
+
+func TestTreeBindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr string
+	}{
+		{"Inline tree", `bt_new({type = "sequence", children = {{type = "action", fn = "ok"}, {type = "action", fn = "slow"}}}, leaves)`, "running:sequence > slow", ""},
+		{"Check reads self", `bt_new({type = "selector", children = {{type = "check", key = "flag"}, {type = "action", fn = "no"}}}, leaves)`, "success:selector > check flag", ""},
+		{"Leaf error", `bt_new({type = "action", fn = "boom"}, leaves)`, "", "leaf boom"},
+		{"Unknown leaf", `bt_new({type = "action", fn = "fly"}, leaves)`, "", `unknown leaf "fly"`},
+		{"Unknown key", `bt_new({type = "wait", secnds = 1}, leaves)`, "", "secnds"},
+		{"Path in name", `bt_new("../spectre", leaves)`, "", "plain file name"},
+		{"Shared subtree", `(function() local ok = {type = "action", fn = "ok"}; return bt_new({type = "sequence", children = {ok, ok, {type = "action", fn = "slow"}}}, leaves) end)()`, "running:sequence > slow", ""},
+		{"Cyclic spec", `(function() local t = {type = "sequence"}; t.children = {t}; return bt_new(t, leaves) end)()`, "", "contains itself"},
+		{"Nested too deep", `(function() local t = {type = "action", fn = "ok"}; for i = 1, 200 do t = {type = "succeed", children = {t}} end; return bt_new(t, leaves) end)()`, "", "deeper than"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			InitLua(w)
+			src := `
+				leaves = {
+					ok = function(self, id) end,
+					no = function(self, id) return false end,
+					slow = function(self, id) return "running" end,
+					boom = function(self, id) error("kaboom") end,
+				}
+				tester = {}
+				function tester.update_state(self, id)
+					self.flag = true
+					self.tree = self.tree or ` + tt.body + `
+					result = bt_tick(self.tree, self, id) .. ":" .. bt_active(self.tree)
+				end`
+			if err := w.LState.DoString(src); err != nil {
+				t.Fatal(err)
+			}
+			id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+			w.AIs.Add(id, &components.AI{ScriptName: "tester.lua"})
+
+			err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("SystemAI() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.LState.GetGlobal("result").String(); got != tt.want {
+				t.Errorf("result = %q, want %q", got, tt.want)
+			}
+			if w.AIs.Get(id).Tree == nil {
+				t.Error("AI.Tree was not recorded for the debug view")
+			}
+		})
+	}
+}
+
+// TestShippedPersonalities runs the real spectre script with every tree in behaviours/
+func TestShippedPersonalities(t *testing.T) {
+	specs, err := bt.LoadDir("../../behaviours")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) == 0 {
+		t.Fatal("no behaviour trees found")
+	}
+	src := shippedSpectre(t)
+
+	for name := range specs {
+		personality, ok := strings.CutPrefix(name, "spectre_")
+		if !ok {
+			continue
+		}
+		t.Run(personality, func(t *testing.T) {
+			w, spectre := chaseFixture(t, src, &components.AI{Personality: personality})
+
+			for i := 0; i < 300; i++ {
+				if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true)); err != nil {
+					t.Fatalf("tick %d: %v", i, err)
+				}
+				SystemPhysics(w, false, false)
+				w.Advance()
+			}
+			if tree := w.AIs.Get(spectre).Tree; tree == nil || tree.Name != name {
+				t.Errorf("AI.Tree = %v, want %s", tree, name)
+			}
+		})
+	}
+}
+
+func TestCheckPersonalities(t *testing.T) {
+	defer func(dir string) { TreeDir = dir }(TreeDir)
+	TreeDir = "../../behaviours"
+	tests := []struct {
+		personality string
+		want        string
+		wantErr     bool
+	}{
+		{"wary", "wary", false},
+		{"", "", false},
+		{"grumpy", "", true},
+		{"../spectre_wary", "", true},
+	}
+
+	levels := make([]level.Level, len(tests))
+	for i, tt := range tests {
+		levels[i].Personality = tt.personality
+	}
+	err := CheckPersonalities(levels)
+	for i, tt := range tests {
+		if levels[i].Personality != tt.want {
+			t.Errorf("personality %q became %q, want %q", tt.personality, levels[i].Personality, tt.want)
+		}
+		if mentioned := err != nil && strings.Contains(err.Error(), fmt.Sprintf("level %d ", i+1)); mentioned != tt.wantErr {
+			t.Errorf("personality %q reported = %v, want %v (%v)", tt.personality, mentioned, tt.wantErr, err)
+		}
+	}
+}
+
+func TestNativeFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		driver     components.AIDriver
+		ticks      int
+		wantNative bool
+		wantScript bool
+	}{
+		{"Missing script falls back at once", "", components.DriverAuto, 1, true, false},
+		{"Script driver never falls back", "", components.DriverScript, 1, false, false},
+		{"Working script keeps control", "spectre = {}\nfunction spectre.update_state(self, id) ran = true end", components.DriverAuto, FallbackAfter + 5, false, true},
+		{"Brief errors are tolerated", "spectre = {}\nfunction spectre.update_state(self, id) error('flaky') end", components.DriverAuto, FallbackAfter, false, false},
+		{"Persistent errors fall back", "spectre = {}\nfunction spectre.update_state(self, id) error('flaky') end", components.DriverAuto, FallbackAfter + 1, true, false},
+		{"Native driver skips the script", "spectre = {}\nfunction spectre.update_state(self, id) ran = true end", components.DriverNative, 1, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ai := &components.AI{Driver: tt.driver}
+			w, spectre := chaseFixture(t, tt.src, ai)
+
+			for i := 0; i < tt.ticks; i++ {
+				if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(false)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := ai.Native != nil; got != tt.wantNative {
+				t.Errorf("native brain ran = %v, want %v", got, tt.wantNative)
+			}
+			if got := w.LState.GetGlobal("ran") == lua.LTrue; got != tt.wantScript {
+				t.Errorf("script ran = %v, want %v", got, tt.wantScript)
+			}
+			if tt.wantNative && w.Physics.Get(spectre).Acceleration == (core.Vector2{}) {
+				t.Error("native brain applied no force")
+			}
+		})
+	}
+}
+
+func TestReloadRetriesScriptAfterFallback(t *testing.T) {
+	w := world.NewHeadlessWorld()
+	InitLua(w)
+	id := spawnBody(w, "spectre", core.Vector2{X: 100, Y: 100}, core.Vector2{})
+	ai := &components.AI{ScriptName: "spectre.lua", Failures: FallbackAfter}
+	w.AIs.Add(id, ai)
+
+	giveScriptAnotherChance(w, "spectre.lua")
+	if ai.Failures != 0 {
+		t.Errorf("Failures = %d after reload, want 0", ai.Failures)
+	}
+}
+
+// BenchmarkSpectre compares the shipped script with its native fallback over the same chase
+func BenchmarkSpectre(b *testing.B) {
+	src := shippedSpectre(b)
+	for _, driver := range []components.AIDriver{components.DriverScript, components.DriverNative} {
+		b.Run(driver.String(), func(b *testing.B) {
+			w, _ := chaseFixture(b, src, &components.AI{Driver: driver})
+			lvl := &level.Level{}
+			diag := NewScriptDiagnostics(true)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := SystemAI(w, lvl, diag); err != nil {
+					b.Fatal(err)
+				}
+				SystemPhysics(w, false, false)
+				w.Advance()
+			}
+		})
+	}
+}
+
+func TestRunnerBotStandsDownForPlayer(t *testing.T) {
+	for _, controlled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("controlled=%v", controlled), func(t *testing.T) {
+			w := world.NewHeadlessWorld()
+			InitLua(w)
+			if err := w.LState.DoFile("../../runner.lua"); err != nil {
+				t.Fatal(err)
+			}
+			SpawnWell(w, core.Vector2{X: 640, Y: 360}, 40, 1.5)
+			runner := spawnBody(w, "runner", core.Vector2{X: 200, Y: 200}, core.Vector2{})
+			spectre := spawnBody(w, "spectre", core.Vector2{X: 500, Y: 300}, core.Vector2{})
+			w.AIs.Add(runner, &components.AI{ScriptName: "runner.lua", TargetID: w.Handle(spectre)})
+			if controlled {
+				w.InputControlleds.Add(runner, &components.InputControlled{})
+			}
+
+			w.UpdateGrid()
+			if err := SystemAI(w, &level.Level{}, NewScriptDiagnostics(true)); err != nil {
+				t.Fatal(err)
+			}
+			moved := w.Physics.Get(runner).Acceleration != (core.Vector2{})
+			if moved == controlled {
+				t.Errorf("bot steered = %v with a player in control = %v", moved, controlled)
+			}
+		})
+	}
+}
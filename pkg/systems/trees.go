@@ -0,0 +1,221 @@
+package systems
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"beautifulmess/pkg/bt"
+	"beautifulmess/pkg/core"
+	"beautifulmess/pkg/level"
+	"beautifulmess/pkg/world"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// TreeDir holds behaviour tree files; scripts name a tree by its file name without ".json"
+var TreeDir = "behaviours"
+
+// CheckPersonalities makes sure each level's spectre personality has a tree in TreeDir. A level
+// naming one that is missing is switched to the default with an error saying so, rather than
+// leaving spectre.lua to fail every tick once the level starts.
+func CheckPersonalities(levels []level.Level) error {
+	var errs []error
+	for i := range levels {
+		lvl := &levels[i]
+		if lvl.Personality == "" { continue }
+		var err error
+		if strings.ContainsAny(lvl.Personality, `/\.`) {
+			err = errors.New("not a plain file name")
+		} else {
+			_, err = os.Stat(filepath.Join(TreeDir, "spectre_"+lvl.Personality+".json"))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("level %d (%s): personality %q has no behaviour tree, using the default: %w", i+1, lvl.Name, lvl.Personality, err))
+			lvl.Personality = ""
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// luaTree is a tree instance owned by a script, plus what its leaves need during the current tick
+type luaTree struct {
+	tree   *bt.Tree
+	leaves *lua.LTable
+	self   *lua.LTable
+	id     core.Entity
+	handle lua.LValue // id as the script passed it, handed on to the leaves
+	err    error // First leaf failure this tick; raised from bt_tick once the tree stops
+}
+
+// luaBlackboard lets check nodes read the script's self table directly
+type luaBlackboard struct{ t *lua.LTable }
+
+func (b luaBlackboard) Get(key string) any {
+	switch v := b.t.RawGetString(key).(type) {
+	case lua.LBool:
+		return bool(v)
+	case lua.LNumber:
+		return float64(v)
+	case lua.LString:
+		return string(v)
+	}
+	return nil
+}
+
+// registerTrees exposes the behaviour-tree runtime. A script builds one tree per entity in init and
+// ticks it from update_state; leaves are functions in a table the script supplies, called as
+// fn(self, id) and returning false for failure, "running" to continue next tick, or anything else for success.
+func registerTrees(L *lua.LState, w *world.World) {
+	// bt_new(name_or_spec, leaves) -> tree
+	L.SetGlobal("bt_new", L.NewFunction(func(L *lua.LState) int {
+		lt := &luaTree{leaves: L.CheckTable(2)}
+		var spec bt.Spec
+		name := "inline"
+		switch arg := L.CheckAny(1).(type) {
+		case lua.LString:
+			name = string(arg)
+			if name == "" || strings.ContainsAny(name, `/\.`) {
+				L.ArgError(1, fmt.Sprintf("tree name %q must be a plain file name", name))
+			}
+			var err error
+			if spec, err = bt.LoadFile(filepath.Join(TreeDir, name+".json")); err != nil {
+				L.RaiseError("loading tree %s: %v", name, err)
+			}
+		case *lua.LTable:
+			var err error
+			if spec, err = specFromLua(arg); err != nil {
+				L.ArgError(1, err.Error())
+			}
+		default:
+			L.ArgError(1, "expected a tree name or spec table")
+		}
+
+		tree, err := bt.Build(name, spec, func(fn string) (bt.Leaf, bool) {
+			if lt.leaves.RawGetString(fn).Type() != lua.LTFunction { return nil, false }
+			return func() bt.Status { return lt.callLeaf(L, fn) }, true
+		})
+		if err != nil {
+			L.RaiseError("tree %s: %v", name, err)
+		}
+		lt.tree = tree
+		ud := L.NewUserData()
+		ud.Value = lt
+		L.Push(ud)
+		return 1
+	}))
+
+	// bt_tick(tree, self, id [, leaves]) -> "success" | "failure" | "running"
+	// Passing leaves again picks up functions replaced by a hot reload.
+	L.SetGlobal("bt_tick", L.NewFunction(func(L *lua.LState) int {
+		lt := checkTree(L, 1)
+		lt.self, lt.id, lt.handle = L.CheckTable(2), checkEntity(L, w, 3), L.Get(3)
+		if leaves, ok := L.Get(4).(*lua.LTable); ok { lt.leaves = leaves }
+
+		status := lt.tree.Tick(w.Tick, luaBlackboard{lt.self})
+		if ai := w.AIs.Get(lt.id); ai != nil { ai.Tree = lt.tree }
+		if err := lt.err; err != nil {
+			lt.err = nil
+			L.RaiseError("%v", err)
+		}
+		L.Push(lua.LString(status.String()))
+		return 1
+	}))
+
+	// bt_active(tree) -> the branch the last tick ended on, e.g. "selector > evade > wait 0.25s"
+	L.SetGlobal("bt_active", L.NewFunction(func(L *lua.LState) int {
+		L.Push(lua.LString(checkTree(L, 1).tree.ActivePath()))
+		return 1
+	}))
+
+	L.SetGlobal("bt_reset", L.NewFunction(func(L *lua.LState) int {
+		checkTree(L, 1).tree.Reset()
+		return 0
+	}))
+}
+
+func checkTree(L *lua.LState, n int) *luaTree {
+	if lt, ok := L.CheckUserData(n).Value.(*luaTree); ok {
+		return lt
+	}
+	L.ArgError(n, "expected a behaviour tree")
+	return nil
+}
+
+func (lt *luaTree) callLeaf(L *lua.LState, name string) bt.Status {
+	// After one leaf fails the rest of the tick is abandoned; bt_tick reports the error
+	if lt.err != nil { return bt.Failure }
+	err := L.CallByParam(lua.P{Fn: lt.leaves.RawGetString(name), NRet: 1, Protect: true}, lt.self, lt.handle)
+	if err != nil {
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) { err = errors.New(apiErr.Object.String()) }
+		lt.err = fmt.Errorf("leaf %s: %w", name, err)
+		return bt.Failure
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	switch {
+	case ret == lua.LFalse:
+		return bt.Failure
+	case ret.Type() == lua.LTString && ret.String() == "running":
+		return bt.Running
+	}
+	return bt.Success
+}
+
+// specFromLua round-trips a spec table through JSON so inline trees get the same strict
+// checking as tree files
+func specFromLua(t *lua.LTable) (bt.Spec, error) {
+	var spec bt.Spec
+	v, err := luaToGo(t, make(map[*lua.LTable]bool), 0)
+	if err != nil { return spec, err }
+	data, err := json.Marshal(v)
+	if err != nil { return spec, err }
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&spec)
+	return spec, err
+}
+
+// maxSpecDepth bounds how deeply a spec table may nest; each tree level is two, the node and its
+// children list. Far deeper than any tree worth writing, far shallower than the Go stack.
+const maxSpecDepth = 128
+
+// luaToGo copies a spec table into plain Go values. open holds the tables being copied further up,
+// so a table that contains itself is reported instead of recursing until the Go stack overflows,
+// which no protected call can recover from; a table shared by two branches is fine.
+func luaToGo(v lua.LValue, open map[*lua.LTable]bool, depth int) (any, error) {
+	switch v := v.(type) {
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		if open[v] { return nil, errors.New("spec table contains itself") }
+		if depth >= maxSpecDepth { return nil, fmt.Errorf("spec nests deeper than %d tables", maxSpecDepth) }
+		open[v] = true
+		defer delete(open, v)
+		if v.MaxN() > 0 {
+			list := make([]any, 0, v.MaxN())
+			for i := 1; i <= v.MaxN(); i++ {
+				item, err := luaToGo(v.RawGetInt(i), open, depth+1)
+				if err != nil { return nil, err }
+				list = append(list, item)
+			}
+			return list, nil
+		}
+		m := make(map[string]any)
+		var err error
+		v.ForEach(func(k, val lua.LValue) {
+			if err != nil { return }
+			m[k.String()], err = luaToGo(val, open, depth+1)
+		})
+		return m, err
+	}
+	return nil, nil
+}
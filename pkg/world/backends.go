@@ -0,0 +1,69 @@
+package world
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+)
+
+// Audio is the sound sink the simulation plays into. Keeping it abstract lets the world
+// run in tests and batch jobs where no audio device exists.
+type Audio interface {
+	Play(name string)
+	PlayAt(name string, offset time.Duration)
+	Stop(name string)
+	IsPlaying(name string) bool
+	LoadFile(name, path string)
+	SetVolume(v float64)
+	SetPlayerVolume(name string, v float64)
+}
+
+// Graphics creates sprites for entities spawned mid-simulation (bullets, walls)
+type Graphics interface {
+	NewSolidSprite(w, h int, c color.RGBA) components.Sprite
+}
+
+// Input reports the player's intent, decoupled from any particular device
+type Input interface {
+	MoveDir() core.Vector2
+	Boost() bool
+}
+
+// TickedInput latches one sample per sim tick, so every reader within a tick sees the same
+// value. Recorders and replay players implement it; World.Advance drives it.
+type TickedInput interface {
+	Input
+	Poll()
+}
+
+// NopAudio discards every sound
+type NopAudio struct{}
+
+func (NopAudio) Play(string)                     {}
+func (NopAudio) PlayAt(string, time.Duration)    {}
+func (NopAudio) Stop(string)                     {}
+func (NopAudio) IsPlaying(string) bool           { return false }
+func (NopAudio) LoadFile(string, string)         {}
+func (NopAudio) SetVolume(float64)               {}
+func (NopAudio) SetPlayerVolume(string, float64) {}
+
+// NopGraphics hands out size-only sprites so collision and scaling code still sees real dimensions
+type NopGraphics struct{}
+
+func (NopGraphics) NewSolidSprite(w, h int, _ color.RGBA) components.Sprite {
+	return NopSprite{W: w, H: h}
+}
+
+// NopSprite is a sprite with dimensions but no pixels
+type NopSprite struct{ W, H int }
+
+func (s NopSprite) Bounds() image.Rectangle { return image.Rect(0, 0, s.W, s.H) }
+
+// NopInput never moves and never boosts
+type NopInput struct{}
+
+func (NopInput) MoveDir() core.Vector2 { return core.Vector2{} }
+func (NopInput) Boost() bool           { return false }
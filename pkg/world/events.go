@@ -0,0 +1,73 @@
+package world
+
+import (
+	"image/color"
+	"reflect"
+
+	"beautifulmess/pkg/core"
+)
+
+// events lets systems report what happened without knowing who cares: they Publish, and audio,
+// effects, stats, scripts and the story Subscribe. Events queue until Dispatch, so handlers never
+// run in the middle of a system's loop. Entities are named by handle because a handler may run
+// after the entity is gone.
+type events struct {
+	handlers map[reflect.Type]any // []func(E) for each event type E
+	queue    []func()
+}
+
+// Subscribe calls fn with every E published from now on, in the order handlers subscribed.
+// Subscriptions outlive Reset; they belong to whatever wired the world up, not to the level.
+func Subscribe[E any](w *World, fn func(E)) {
+	if w.events.handlers == nil { w.events.handlers = make(map[reflect.Type]any) }
+	t := reflect.TypeFor[E]()
+	fns, _ := w.events.handlers[t].([]func(E))
+	w.events.handlers[t] = append(fns, fn)
+}
+
+// Publish queues e for E's subscribers; they hear about it at the next Dispatch
+func Publish[E any](w *World, e E) {
+	w.events.queue = append(w.events.queue, func() {
+		fns, _ := w.events.handlers[reflect.TypeFor[E]()].([]func(E))
+		for _, fn := range fns {
+			fn(e)
+		}
+	})
+}
+
+// Dispatch delivers queued events in the order they were published, including any the handlers
+// publish themselves. The game calls it once per tick, after the systems have run.
+func (w *World) Dispatch() {
+	for i := 0; i < len(w.events.queue); i++ {
+		w.events.queue[i]()
+		w.events.queue[i] = nil
+	}
+	w.events.queue = w.events.queue[:0]
+}
+
+// SpectreHit is a bullet striking a spectre, which makes wells pull on it harder
+type SpectreHit struct {
+	Spectre, Bullet core.Handle  // The bullet is destroyed by the hit
+	Position        core.Vector2 // The spectre's
+	Velocity        core.Vector2 // The bullet's, for debris to carry on with
+}
+
+// WallShattered is a bullet breaking a destructible wall
+type WallShattered struct {
+	Wall, Bullet core.Handle  // The wall is already destroyed when handlers run
+	Position     core.Vector2 // The wall's
+	Velocity     core.Vector2 // The bullet's after bouncing off it
+	Color        color.RGBA   // The wall's, since it can no longer be looked up; zero if it was never drawn
+}
+
+// BulletBounced is a bullet glancing off a wall that holds
+type BulletBounced struct {
+	Bullet, Wall core.Handle
+	Position     core.Vector2 // The bullet's, pushed back out of the wall
+}
+
+// SpectreCaught is the runner pinning the spectre inside a well, which wins the level
+type SpectreCaught struct {
+	Spectre, Runner core.Handle
+	Position        core.Vector2 // The spectre's, where the two meet again
+}
@@ -0,0 +1,52 @@
+package world
+
+import (
+	"slices"
+	"testing"
+
+	"beautifulmess/pkg/core"
+)
+
+func TestEvents(t *testing.T) {
+	type ping struct{ n int }
+	type pong struct{ n int }
+	w := NewHeadlessWorld()
+	var got []string
+	Subscribe(w, func(e ping) {
+		got = append(got, "ping")
+		// Handlers may publish; that is delivered in the same Dispatch
+		if e.n == 1 {
+			Publish(w, pong{2})
+		}
+	})
+	Subscribe(w, func(e ping) { got = append(got, "ping again") })
+	Subscribe(w, func(e pong) { got = append(got, "pong") })
+
+	Publish(w, ping{1})
+	Publish(w, SpectreHit{}) // Nobody listens
+	Publish(w, pong{1})
+	if len(got) != 0 {
+		t.Fatalf("handled %v before Dispatch", got)
+	}
+	w.Dispatch()
+	if want := []string{"ping", "ping again", "pong", "pong"}; !slices.Equal(got, want) {
+		t.Errorf("Dispatch delivered %v, want %v", got, want)
+	}
+
+	got = nil
+	w.Dispatch()
+	if len(got) != 0 {
+		t.Errorf("second Dispatch delivered %v again", got)
+	}
+
+	// A level load drops what is queued but keeps the subscribers
+	Publish(w, pong{3})
+	w.Reset()
+	w.Dispatch()
+	Publish(w, WallShattered{Wall: core.Handle{ID: 1, Gen: 1}})
+	Publish(w, pong{4})
+	w.Dispatch()
+	if want := []string{"pong"}; !slices.Equal(got, want) {
+		t.Errorf("after Reset delivered %v, want %v", got, want)
+	}
+}
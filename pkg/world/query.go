@@ -0,0 +1,194 @@
+package world
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sort"
+
+	"beautifulmess/pkg/core"
+)
+
+// Grid dimensions; cells are GridCell pixels square and the last row and column are partial
+const (
+	GridCell = 100
+	GridCols = 13
+	GridRows = 8
+)
+
+// Hit is one entity found by a spatial query, with its wrapped distance from the query point
+type Hit struct {
+	ID   core.Entity
+	Pos  core.Vector2
+	Dist float64
+}
+
+// sortHits orders by distance, then ID, so scripts see the same order every run
+func sortHits(hits []Hit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Dist != hits[j].Dist {
+			return hits[i].Dist < hits[j].Dist
+		}
+		return hits[i].ID < hits[j].ID
+	})
+}
+
+// WallsNear returns intact walls whose centres lie within r of p, nearest first; p may be any
+// number of screens away. It reads the spatial grid, so UpdateGrid must have run this tick.
+func (w *World) WallsNear(p core.Vector2, r float64) []Hit {
+	var hits []Hit
+	core.WrapFully(&p)
+	if math.IsNaN(p.X) || math.IsNaN(p.Y) || !(r >= 0) { return nil }
+	// Cells are visited once even when the radius spans the whole wrapped grid
+	reach := int(math.Ceil(math.Min(r, core.ScreenWidth)/GridCell)) + 1
+	cols, rows := min(2*reach+1, GridCols), min(2*reach+1, GridRows)
+	gx, gy := int(p.X/GridCell), int(p.Y/GridCell)
+	for i := 0; i < cols; i++ {
+		for j := 0; j < rows; j++ {
+			tx := ((gx-reach+i)%GridCols + GridCols) % GridCols
+			ty := ((gy-reach+j)%GridRows + GridRows) % GridRows
+			for _, id := range w.Grid[tx][ty] {
+				wall, trans := w.Walls.Get(id), w.Transforms.Get(id)
+				if wall == nil || wall.IsDestroyed || trans == nil { continue }
+				if d := core.DistWrapped(p, trans.Position); d <= r {
+					hits = append(hits, Hit{ID: id, Pos: trans.Position, Dist: d})
+				}
+			}
+		}
+	}
+	sortHits(hits)
+	return hits
+}
+
+// Wells lists every gravity well, in entity order
+func (w *World) Wells() []core.Entity {
+	wells := slices.Collect(Query(w.GravityWells, w.Transforms))
+	// Stores keep no particular order, and scripts index this list
+	slices.Sort(wells)
+	return wells
+}
+
+// DrawOrder lists every entity with a Render in the order to draw them: by layer, then by entity.
+// Stores reorder as entries are removed, and drawing in store order would make overlapping sprites
+// swap places whenever something else was destroyed.
+func (w *World) DrawOrder() []core.Entity {
+	ids := make([]core.Entity, 0, w.Renders.Len())
+	for id := range w.Renders.All() {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b core.Entity) int {
+		if la, lb := w.Renders.Get(a).Layer, w.Renders.Get(b).Layer; la != lb { return cmp.Compare(la, lb) }
+		return cmp.Compare(a, b)
+	})
+	return ids
+}
+
+// EntitiesByTag finds tagged entities within r of p, nearest first
+func (w *World) EntitiesByTag(tag string, p core.Vector2, r float64) []Hit {
+	var hits []Hit
+	for id := range Query(w.Tags, w.Transforms) {
+		t, trans := w.Tags.Get(id), w.Transforms.Get(id)
+		if t.Name != tag { continue }
+		if d := core.DistWrapped(p, trans.Position); d <= r {
+			hits = append(hits, Hit{ID: id, Pos: trans.Position, Dist: d})
+		}
+	}
+	sortHits(hits)
+	return hits
+}
+
+// RaycastStep is the marching distance; a quarter of a wall keeps thin walls from being skipped
+const RaycastStep = 2.5
+
+// MaxRayDist caps how far rays and traced shots travel. Several screens' worth covers any line of
+// sight that wraps back into view, and keeps a careless distance from stalling the frame in Go
+// code the script budget cannot interrupt.
+const MaxRayDist = 4 * (core.ScreenWidth + core.ScreenHeight)
+
+// Raycast marches from origin along dir, wrapping across the screen edges like everything else,
+// and reports the first wall it enters within maxDist, which is capped at MaxRayDist
+func (w *World) Raycast(origin, dir core.Vector2, maxDist float64) (Hit, bool) {
+	l := math.Hypot(dir.X, dir.Y)
+	if l == 0 || !(maxDist > 0) {
+		return Hit{}, false
+	}
+	maxDist = math.Min(maxDist, MaxRayDist)
+	dx, dy := dir.X/l, dir.Y/l
+	for t := 0.0; t <= maxDist; t += RaycastStep {
+		p := core.Vector2{X: origin.X + dx*t, Y: origin.Y + dy*t}
+		core.WrapFully(&p)
+		if id, ok := w.wallAt(p); ok {
+			return Hit{ID: id, Pos: p, Dist: t}, true
+		}
+	}
+	return Hit{}, false
+}
+
+// Shot is where a traced bullet passed closest to its target
+type Shot struct {
+	Miss    float64 // Closest approach to the target
+	Dist    float64 // Distance the bullet had flown by then
+	Bounces int     // Walls it bounced off on the way
+}
+
+// Bullet geometry mirrored from the physics: the speed it flies at and how far from a wall's edge it bounces
+const (
+	ShotSpeed  = 8.0
+	ShotMargin = 5.0
+)
+
+// TraceShot follows a bullet fired from origin along dir, bouncing off walls the way the physics
+// does, for at most maxDist (capped at MaxRayDist) and maxBounces bounces. Walls it would destroy
+// are treated as intact.
+func (w *World) TraceShot(origin, dir, target core.Vector2, maxDist float64, maxBounces int) Shot {
+	l := math.Hypot(dir.X, dir.Y)
+	best := Shot{Miss: core.DistWrapped(origin, target)}
+	if l == 0 { return best }
+	maxDist, maxBounces = math.Min(maxDist, MaxRayDist), max(maxBounces, 0)
+	v := core.Vector2{X: dir.X / l * ShotSpeed, Y: dir.Y / l * ShotSpeed}
+	p, bounces := origin, 0
+	for t := ShotSpeed; t <= maxDist; t += ShotSpeed {
+		next := core.Vector2{X: p.X + v.X, Y: p.Y + v.Y}
+		core.WrapFully(&next)
+		if id, ok := w.wallWithin(next, ShotMargin); ok {
+			if bounces == maxBounces { break }
+			bounces++
+			// Bounce off the face the bullet is deeper behind, staying where it was like the physics' push-out
+			d := core.VecToWrapped(next, w.Transforms.Get(id).Position)
+			if math.Abs(d.X) > math.Abs(d.Y) { v.X = -v.X } else { v.Y = -v.Y }
+			continue
+		}
+		p = next
+		if d := core.DistWrapped(p, target); d < best.Miss {
+			best = Shot{Miss: d, Dist: t, Bounces: bounces}
+		}
+	}
+	return best
+}
+
+// wallAt finds an intact wall whose square footprint covers p
+func (w *World) wallAt(p core.Vector2) (core.Entity, bool) {
+	return w.wallWithin(p, 0)
+}
+
+// wallWithin finds an intact wall whose footprint, grown by margin on every side, covers p.
+// It wraps p itself rather than trusting callers to, since a point off the grid indexes out of range.
+func (w *World) wallWithin(p core.Vector2, margin float64) (core.Entity, bool) {
+	core.WrapFully(&p)
+	if math.IsNaN(p.X) || math.IsNaN(p.Y) { return 0, false } // Also what an infinity wraps to
+	gx, gy := int(p.X/GridCell), int(p.Y/GridCell)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			tx, ty := (gx+dx+GridCols)%GridCols, (gy+dy+GridRows)%GridRows
+			for _, id := range w.Grid[tx][ty] {
+				wall, trans := w.Walls.Get(id), w.Transforms.Get(id)
+				if wall == nil || wall.IsDestroyed || trans == nil { continue }
+				d := core.VecToWrapped(p, trans.Position)
+				if half := wall.Size/2 + margin; math.Abs(d.X) <= half && math.Abs(d.Y) <= half {
+					return id, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,191 @@
+package world
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+)
+
+func addWall(w *World, x, y float64, destroyed bool) core.Entity {
+	id := w.CreateEntity()
+	w.Transforms.Add(id, &components.Transform{Position: core.Vector2{X: x, Y: y}})
+	w.Walls.Add(id, &components.Wall{Size: 10, IsDestroyed: destroyed})
+	return id
+}
+
+func TestWallsNear(t *testing.T) {
+	w := NewHeadlessWorld()
+	near := addWall(w, 110, 100, false)
+	far := addWall(w, 400, 100, false)
+	edge := addWall(w, 5, 100, false) // Across the seam from x=1275
+	addWall(w, 120, 100, true)
+	w.UpdateGrid()
+
+	tests := []struct {
+		name string
+		p    core.Vector2
+		r    float64
+		want []core.Entity
+	}{
+		{"Nearest first, destroyed skipped", core.Vector2{X: 100, Y: 100}, 50, []core.Entity{near}},
+		{"Large radius", core.Vector2{X: 100, Y: 100}, 300, []core.Entity{near, edge, far}},
+		{"Wraps horizontally", core.Vector2{X: 1275, Y: 100}, 20, []core.Entity{edge}},
+		{"Nothing in range", core.Vector2{X: 640, Y: 600}, 30, nil},
+		{"Point screens away", core.Vector2{X: 100 + 3*core.ScreenWidth, Y: 100 - 2*core.ScreenHeight}, 50, []core.Entity{near}},
+		{"Radius past the whole screen", core.Vector2{X: 100, Y: 100}, 1e300, []core.Entity{near, edge, far}},
+		{"NaN point", core.Vector2{X: math.NaN(), Y: 100}, 50, nil},
+		{"NaN radius", core.Vector2{X: 100, Y: 100}, math.NaN(), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := w.WallsNear(tt.p, tt.r)
+			if len(hits) != len(tt.want) {
+				t.Fatalf("WallsNear() = %+v, want ids %v", hits, tt.want)
+			}
+			for i, h := range hits {
+				if h.ID != tt.want[i] {
+					t.Errorf("hit %d = %d, want %d", i, h.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRaycast(t *testing.T) {
+	w := NewHeadlessWorld()
+	block := addWall(w, 300, 100, false)
+	seam := addWall(w, 20, 400, false)
+	w.UpdateGrid()
+
+	tests := []struct {
+		name     string
+		origin   core.Vector2
+		dir      core.Vector2
+		maxDist  float64
+		wantHit  bool
+		wantID   core.Entity
+		wantDist float64
+	}{
+		{"Straight hit", core.Vector2{X: 100, Y: 100}, core.Vector2{X: 1}, 500, true, block, 195},
+		{"Out of range", core.Vector2{X: 100, Y: 100}, core.Vector2{X: 1}, 150, false, 0, 0},
+		{"Miss", core.Vector2{X: 100, Y: 100}, core.Vector2{Y: 1}, 500, false, 0, 0},
+		{"Through the seam", core.Vector2{X: 1200, Y: 400}, core.Vector2{X: 2}, 500, true, seam, 95},
+		{"Zero direction", core.Vector2{X: 100, Y: 100}, core.Vector2{}, 500, false, 0, 0},
+		{"Range past the cap", core.Vector2{X: 100, Y: 50}, core.Vector2{X: 1}, 1e15, false, 0, 0},
+		{"NaN range", core.Vector2{X: 100, Y: 100}, core.Vector2{X: 1}, math.NaN(), false, 0, 0},
+		{"Origin screens away", core.Vector2{X: 100 - 5*core.ScreenWidth, Y: 100}, core.Vector2{X: 1}, 500, true, block, 195},
+		{"NaN origin", core.Vector2{X: math.NaN(), Y: 100}, core.Vector2{X: 1}, 500, false, 0, 0},
+		{"Infinite direction", core.Vector2{X: 100, Y: 100}, core.Vector2{X: math.Inf(1)}, 500, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, ok := w.Raycast(tt.origin, tt.dir, tt.maxDist)
+			if ok != tt.wantHit {
+				t.Fatalf("Raycast() hit = %v, want %v (%+v)", ok, tt.wantHit, h)
+			}
+			if !ok {
+				return
+			}
+			if h.ID != tt.wantID {
+				t.Errorf("hit wall %d, want %d", h.ID, tt.wantID)
+			}
+			if math.Abs(h.Dist-tt.wantDist) > RaycastStep {
+				t.Errorf("hit at distance %v, want about %v", h.Dist, tt.wantDist)
+			}
+		})
+	}
+}
+
+func TestEntitiesByTag(t *testing.T) {
+	w := NewHeadlessWorld()
+	spawn := func(tag string, x, y float64) core.Entity {
+		id := w.CreateEntity()
+		w.Tags.Add(id, &components.Tag{Name: tag})
+		w.Transforms.Add(id, &components.Transform{Position: core.Vector2{X: x, Y: y}})
+		return id
+	}
+	b1 := spawn("bullet", 150, 100)
+	spawn("runner", 110, 100)
+	b2 := spawn("bullet", 120, 100)
+	b3 := spawn("bullet", 100, 715) // Wraps vertically to 5px away
+	spawn("bullet", 600, 600)
+
+	hits := w.EntitiesByTag("bullet", core.Vector2{X: 100, Y: 0}, 160)
+	want := []core.Entity{b3, b2, b1}
+	if len(hits) != len(want) {
+		t.Fatalf("EntitiesByTag() = %+v, want ids %v", hits, want)
+	}
+	for i, h := range hits {
+		if h.ID != want[i] {
+			t.Errorf("hit %d = %d, want %d", i, h.ID, want[i])
+		}
+	}
+}
+
+func TestTraceShot(t *testing.T) {
+	w := NewHeadlessWorld()
+	addWall(w, 300, 100, false)
+	w.UpdateGrid()
+
+	tests := []struct {
+		name        string
+		target      core.Vector2
+		maxBounces  int
+		wantHit     bool
+		wantBounces int
+	}{
+		{"Direct", core.Vector2{X: 260, Y: 100}, 0, true, 0},
+		{"Bank shot comes back", core.Vector2{X: 120, Y: 100}, 1, true, 1},
+		{"Bank shot not allowed", core.Vector2{X: 120, Y: 100}, 0, false, 0},
+		{"Negative bounce limit", core.Vector2{X: 120, Y: 100}, -1, false, 0},
+		{"Off to the side", core.Vector2{X: 260, Y: 200}, 1, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shot := w.TraceShot(core.Vector2{X: 200, Y: 100}, core.Vector2{X: 1}, tt.target, 500, tt.maxBounces)
+			if hit := shot.Miss < ShotSpeed; hit != tt.wantHit {
+				t.Fatalf("TraceShot() = %+v, want hit %v", shot, tt.wantHit)
+			}
+			if hit := shot.Miss < ShotSpeed; hit && shot.Bounces != tt.wantBounces {
+				t.Errorf("Bounces = %d, want %d", shot.Bounces, tt.wantBounces)
+			}
+		})
+	}
+
+	// Direct Go callers get no argument checks, so a NaN origin must not reach the grid
+	if shot := w.TraceShot(core.Vector2{X: math.NaN(), Y: 100}, core.Vector2{X: 1}, core.Vector2{X: 260, Y: 100}, 500, 1); shot.Bounces != 0 {
+		t.Errorf("TraceShot() from a NaN origin = %+v, want no bounces", shot)
+	}
+}
+
+func TestDrawOrder(t *testing.T) {
+	w := NewHeadlessWorld()
+	add := func(layer components.RenderLayer) core.Entity {
+		id := w.CreateEntity()
+		w.Renders.Add(id, &components.Render{Layer: layer})
+		return id
+	}
+	bullet := add(components.LayerBullets)
+	wallA := add(components.LayerWalls)
+	ship := add(components.LayerShips)
+	doomed := add(components.LayerWalls)
+	wallB := add(components.LayerWalls)
+	decoy := add(components.LayerDecoys)
+
+	want := []core.Entity{wallA, doomed, wallB, decoy, ship, bullet}
+	if got := w.DrawOrder(); !slices.Equal(got, want) {
+		t.Fatalf("DrawOrder() = %v, want %v", got, want)
+	}
+	// Removing an entry moves the last one into its place in the store; the draw order must not follow
+	w.DestroyEntity(doomed)
+	want = []core.Entity{wallA, wallB, decoy, ship, bullet}
+	if got := w.DrawOrder(); !slices.Equal(got, want) {
+		t.Errorf("after a removal DrawOrder() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package world
+
+import (
+	"strings"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// MaxScriptString caps the strings string.rep builds. A single call runs as one instruction, so
+// the script budget cannot stop it from asking for more memory than the machine has.
+const MaxScriptString = 1 << 20
+
+// sandboxLibs are the only standard libraries scripts get; os, io, package and debug stay closed
+var sandboxLibs = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+	{lua.CoroutineLibName, lua.OpenCoroutine},
+}
+
+// unsafeGlobals are base functions that reach the filesystem, load arbitrary chunks or escape environments
+var unsafeGlobals = []string{
+	"dofile", "loadfile", "load", "loadstring", "require", "module",
+	"getfenv", "setfenv", "collectgarbage", "newproxy", "_printregs",
+}
+
+// NewSandboxedState creates a Lua VM that user-made scripts cannot use to touch the host.
+// Go can still load files into it; scripts themselves cannot.
+func NewSandboxedState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range sandboxLibs {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+	// The string table is also the strings' metatable index, so ("x"):rep(n) gets these too
+	str := L.GetGlobal(lua.StringLibName).(*lua.LTable)
+	str.RawSetString("rep", L.NewFunction(strRep))
+	str.RawSetString("format", L.NewFunction(boundedFormat(str.RawGetString("format").(*lua.LFunction))))
+	return L
+}
+
+// strRep is string.rep refusing to build anything longer than MaxScriptString
+func strRep(L *lua.LState) int {
+	s, n := L.CheckString(1), L.CheckInt(2)
+	if n > 0 && len(s) > 0 && n > MaxScriptString/len(s) {
+		L.RaiseError("string.rep would build more than %d bytes", MaxScriptString)
+	}
+	L.Push(lua.LString(strings.Repeat(s, max(n, 0))))
+	return 1
+}
+
+// boundedFormat wraps string.format with Lua's own limit of two digits for a width or precision;
+// the library hands the pattern straight to Go, which pads to whatever it is asked
+func boundedFormat(format *lua.LFunction) lua.LGFunction {
+	return func(L *lua.LState) int {
+		pattern := L.CheckString(1)
+		for i := 0; i < len(pattern); i++ {
+			if pattern[i] != '%' { continue }
+			i++
+			for i < len(pattern) && strings.IndexByte("-+ #0", pattern[i]) >= 0 {
+				i++
+			}
+			for _, part := range []string{"width", "precision"} {
+				digits := 0
+				for ; i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9'; i++ {
+					digits++
+				}
+				if digits > 2 { L.RaiseError("invalid format (%s too long)", part) }
+				if part == "width" && i < len(pattern) && pattern[i] == '.' { i++ } else { break }
+			}
+		}
+		return format.GFunction(L)
+	}
+}
@@ -0,0 +1,132 @@
+package world
+
+import (
+	"iter"
+
+	"beautifulmess/pkg/core"
+)
+
+// Store holds one component type: a sparse index from entity to a dense list of the entities
+// that have the component, so lookups are O(1) and iteration never visits empty slots
+type Store[T any] struct {
+	at    []int // Position of each entity in ids and items, or -1
+	ids   []core.Entity
+	items []*T
+
+	// Iteration works on a copy of ids so systems can create and destroy mid-loop; finished
+	// copies are kept for the next loop, one per level of nesting
+	spare [][]core.Entity
+}
+
+// AnyStore is a Store seen without its component type, for the world's bookkeeping and for Query
+type AnyStore interface {
+	Has(id core.Entity) bool
+	Remove(id core.Entity)
+	Len() int
+	clear()
+	snapshot() []core.Entity
+	release(ids []core.Entity)
+}
+
+// Register gives w a store for T that DestroyEntity and Reset will keep tidy; adding a component
+// to the game is one Register line plus the World field it is kept in
+func Register[T any](w *World) *Store[T] {
+	s := &Store[T]{}
+	w.stores = append(w.stores, s)
+	return s
+}
+
+// Add gives id the component c, replacing any it had; a nil c removes it. It returns c.
+func (s *Store[T]) Add(id core.Entity, c *T) *T {
+	if c == nil {
+		s.Remove(id)
+		return nil
+	}
+	for int(id) >= len(s.at) {
+		s.at = append(s.at, -1)
+	}
+	if i := s.at[id]; i >= 0 {
+		s.items[i] = c
+		return c
+	}
+	s.at[id] = len(s.ids)
+	s.ids = append(s.ids, id)
+	s.items = append(s.items, c)
+	return c
+}
+
+// Get is id's component, or nil when it has none
+func (s *Store[T]) Get(id core.Entity) *T {
+	if id < 0 || int(id) >= len(s.at) || s.at[id] < 0 { return nil }
+	return s.items[s.at[id]]
+}
+
+// Has reports whether id has the component
+func (s *Store[T]) Has(id core.Entity) bool {
+	return id >= 0 && int(id) < len(s.at) && s.at[id] >= 0
+}
+
+// Remove takes the component off id, moving the last entry into its place
+func (s *Store[T]) Remove(id core.Entity) {
+	if !s.Has(id) { return }
+	i, last := s.at[id], len(s.ids)-1
+	moved := s.ids[last]
+	s.ids[i], s.items[i], s.at[moved] = moved, s.items[last], i
+	s.items[last] = nil // Drop the reference so the component can be collected
+	s.ids, s.items, s.at[id] = s.ids[:last], s.items[:last], -1
+}
+
+// Len is how many entities have the component
+func (s *Store[T]) Len() int { return len(s.ids) }
+
+// All iterates the entities that had the component when the loop started, skipping any that lose
+// it before their turn, so systems can create and destroy mid-loop. The one catch: an entity
+// created in a slot freed earlier in the same loop takes that slot's turn.
+func (s *Store[T]) All() iter.Seq2[core.Entity, *T] {
+	return func(yield func(core.Entity, *T) bool) {
+		ids := s.snapshot()
+		defer s.release(ids)
+		for _, id := range ids {
+			if c := s.Get(id); c != nil && !yield(id, c) { return }
+		}
+	}
+}
+
+func (s *Store[T]) clear() {
+	clear(s.items)
+	s.at, s.ids, s.items = s.at[:0], s.ids[:0], s.items[:0]
+}
+
+func (s *Store[T]) snapshot() []core.Entity {
+	var ids []core.Entity
+	if n := len(s.spare); n > 0 {
+		ids, s.spare = s.spare[n-1], s.spare[:n-1]
+	}
+	return append(ids, s.ids...)
+}
+
+func (s *Store[T]) release(ids []core.Entity) {
+	s.spare = append(s.spare, ids[:0])
+}
+
+// Query iterates the entities that have a component in every one of stores, walking only the
+// smallest of them, e.g. Query(w.Transforms, w.Physics, w.Tags). Like Store.All it tolerates
+// entities being created and destroyed mid-loop.
+func Query(stores ...AnyStore) iter.Seq[core.Entity] {
+	return func(yield func(core.Entity) bool) {
+		if len(stores) == 0 { return }
+		lead := stores[0]
+		for _, s := range stores[1:] {
+			if s.Len() < lead.Len() { lead = s }
+		}
+		ids := lead.snapshot()
+		defer lead.release(ids)
+	next:
+		for _, id := range ids {
+			for _, s := range stores {
+				if s != lead && !s.Has(id) { continue next }
+			}
+			if !yield(id) { return }
+		}
+	}
+}
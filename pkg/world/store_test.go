@@ -0,0 +1,103 @@
+package world
+
+import (
+	"slices"
+	"testing"
+
+	"beautifulmess/pkg/components"
+	"beautifulmess/pkg/core"
+)
+
+func TestStore(t *testing.T) {
+	type shield struct{ hp int }
+	w := NewHeadlessWorld()
+	shields := Register[shield](w)
+	a, b, c := w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+	shields.Add(a, &shield{1})
+	shields.Add(b, &shield{2})
+	shields.Add(c, &shield{3})
+	shields.Add(b, &shield{20}) // Replaces rather than adding twice
+	shields.Remove(a)
+	shields.Add(c, nil) // Same as Remove
+
+	tests := []struct {
+		name    string
+		id      core.Entity
+		wantHas bool
+		wantHP  int
+	}{
+		{"Removed", a, false, 0},
+		{"Replaced", b, true, 20},
+		{"Added nil", c, false, 0},
+		{"Never created", 50, false, 0},
+		{"Negative", -1, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shields.Get(tt.id)
+			if shields.Has(tt.id) != tt.wantHas || (got != nil) != tt.wantHas {
+				t.Fatalf("Has(%d) = %v, Get = %+v, want has %v", tt.id, shields.Has(tt.id), got, tt.wantHas)
+			}
+			if got != nil && got.hp != tt.wantHP {
+				t.Errorf("Get(%d).hp = %d, want %d", tt.id, got.hp, tt.wantHP)
+			}
+		})
+	}
+	if shields.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", shields.Len())
+	}
+
+	// A registered store is cleaned up with the entity and the level
+	w.DestroyEntity(b)
+	if shields.Has(b) {
+		t.Error("DestroyEntity left the component behind")
+	}
+	d := w.CreateEntity()
+	shields.Add(d, &shield{4})
+	w.Reset()
+	if shields.Len() != 0 || shields.Has(d) {
+		t.Errorf("Reset left %d components", shields.Len())
+	}
+}
+
+func TestQuery(t *testing.T) {
+	w := NewHeadlessWorld()
+	body := func(tag string, phys bool) core.Entity {
+		id := w.CreateEntity()
+		w.Transforms.Add(id, &components.Transform{})
+		if tag != "" {
+			w.Tags.Add(id, &components.Tag{Name: tag})
+		}
+		if phys {
+			w.Physics.Add(id, &components.Physics{})
+		}
+		return id
+	}
+	ship := body("runner", true)
+	bullet := body("bullet", true)
+	body("", true)      // Untagged
+	body("wall", false) // No physics
+	w.CreateEntity()    // Nothing at all
+	spectre := body("spectre", true)
+
+	got := slices.Sorted(Query(w.Transforms, w.Physics, w.Tags))
+	if want := []core.Entity{ship, bullet, spectre}; !slices.Equal(got, want) {
+		t.Errorf("Query(Transforms, Physics, Tags) = %v, want %v", got, want)
+	}
+	if got := slices.Collect(Query()); len(got) != 0 {
+		t.Errorf("Query() = %v, want nothing", got)
+	}
+
+	// Destroying an entity that has not been visited yet skips it; new entities wait for the next loop
+	var seen []core.Entity
+	for id := range Query(w.Tags, w.Physics) {
+		seen = append(seen, id)
+		if id == ship {
+			body("decoy", true)
+			w.DestroyEntity(spectre)
+		}
+	}
+	if slices.Contains(seen, spectre) || len(seen) != 2 {
+		t.Errorf("visited %v while destroying %d mid-loop, want the two survivors", seen, spectre)
+	}
+}
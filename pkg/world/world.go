@@ -1,7 +1,8 @@
 package world
 
 import (
-	"beautifulmess/pkg/audio"
+	"math/rand"
+
 	"beautifulmess/pkg/components"
 	"beautifulmess/pkg/core"
 	"beautifulmess/pkg/particles"
@@ -10,124 +11,220 @@ import (
 )
 
 type World struct {
-	// Pointer-slices provide O(1) access with safe nil-validation for stable IDs
-	Transforms       []*components.Transform
-	Physics          []*components.Physics
-	Renders          []*components.Render
-	AIs              []*components.AI
-	Tags             []*components.Tag
-	GravityWells     []*components.GravityWell
-	InputControlleds []*components.InputControlled
-	Walls            []*components.Wall
-	ProjectileEmitters []*components.ProjectileEmitter
-	Lifetimes        []*components.Lifetime
+	// Stores give O(1) access by entity and let systems iterate only the entities that match
+	Transforms       *Store[components.Transform]
+	Physics          *Store[components.Physics]
+	Renders          *Store[components.Render]
+	AIs              *Store[components.AI]
+	Tags             *Store[components.Tag]
+	GravityWells     *Store[components.GravityWell]
+	InputControlleds *Store[components.InputControlled]
+	Walls            *Store[components.Wall]
+	ProjectileEmitters *Store[components.ProjectileEmitter]
+	Lifetimes        *Store[components.Lifetime]
+	stores           []AnyStore
 	
-	// Active lists allow systems to skip empty slots, maintaining high ALU throughput
+	// Every live entity, component or not; activeAt is each slot's index in it, or -1
 	ActiveEntities []core.Entity 
-	ActiveWalls    []core.Entity
+	activeAt       []int
+	// Destroyed slots wait here for CreateEntity, so a long session of bullets does not grow the world
+	free []core.Entity
 
 	// A Spatial Hash Grid optimizes static geometry queries to O(1) neighborhood checks
-	Grid [13][8][]core.Entity 
+	Grid [GridCols][GridRows][]core.Entity 
 
 	Particles *particles.ParticleSystem
-	Audio     *audio.AudioSystem
+	Audio     Audio
+	Gfx       Graphics
+	Input     Input
 	
 	ScreenShake float64
 	LState      *lua.LState
+	events      events
 	nextID      core.Entity
+	// generations outlive Reset so handles taken before a level load stay dead after it
+	generations []uint32
+
+	// Tick is the simulation clock. It only moves in Advance, so pauses and hit-stop freeze game time.
+	Tick uint64
+	// RNG feeds every gameplay decision (including Lua's math.random) so a seed reproduces a run
+	RNG *rand.Rand
 }
 
-func NewWorld() *World {
+// NewWorld wires the simulation to its presentation backends
+func NewWorld(audio Audio, gfx Graphics, input Input) *World {
 	w := &World{
 		Particles: particles.NewParticleSystem(),
-		Audio:     audio.NewAudioSystem(),
-		LState:    lua.NewState(),
+		Audio:     audio,
+		Gfx:       gfx,
+		Input:     input,
+		LState:    NewSandboxedState(),
+		RNG:       rand.New(rand.NewSource(1)),
 	}
+	w.Transforms = Register[components.Transform](w)
+	w.Physics = Register[components.Physics](w)
+	w.Renders = Register[components.Render](w)
+	w.AIs = Register[components.AI](w)
+	w.Tags = Register[components.Tag](w)
+	w.GravityWells = Register[components.GravityWell](w)
+	w.InputControlleds = Register[components.InputControlled](w)
+	w.Walls = Register[components.Wall](w)
+	w.ProjectileEmitters = Register[components.ProjectileEmitter](w)
+	w.Lifetimes = Register[components.Lifetime](w)
 	w.Reset()
 	return w
 }
 
+// NewHeadlessWorld runs the full simulation with silent, invisible, idle backends for tests and servers
+func NewHeadlessWorld() *World {
+	return NewWorld(NopAudio{}, NopGraphics{}, NopInput{})
+}
+
 func (w *World) Reset() {
-	// Slice truncation retains capacity to eliminate heap churn during level resets
-	w.Transforms, w.Physics, w.Renders = w.Transforms[:0], w.Physics[:0], w.Renders[:0]
-	w.AIs, w.Tags, w.GravityWells = w.AIs[:0], w.Tags[:0], w.GravityWells[:0]
-	w.InputControlleds, w.Walls = w.InputControlleds[:0], w.Walls[:0]
-	w.ProjectileEmitters, w.Lifetimes = w.ProjectileEmitters[:0], w.Lifetimes[:0]
-	
-	w.ActiveEntities = w.ActiveEntities[:0]
-	w.ActiveWalls = w.ActiveWalls[:0]
+	// Every slot in use dies with the level, whatever later claims its index
+	for id := range w.activeAt {
+		w.retire(core.Entity(id))
+	}
+
+	// Truncation retains capacity to eliminate heap churn during level resets
+	for _, s := range w.stores {
+		s.clear()
+	}
+	w.ActiveEntities, w.activeAt = w.ActiveEntities[:0], w.activeAt[:0]
+	w.free = w.free[:0]
 	
-	for x := 0; x < 13; x++ {
-		for y := 0; y < 8; y++ {
+	for x := 0; x < GridCols; x++ {
+		for y := 0; y < GridRows; y++ {
 			w.Grid[x][y] = w.Grid[x][y][:0]
 		}
 	}
 	
+	// Events still queued from the old level would name entities that no longer exist
+	clear(w.events.queue)
+	w.events.queue = w.events.queue[:0]
+
 	w.nextID = 0
 	w.ScreenShake = 0
+	w.Tick = 0
+}
+
+// Advance moves the simulation clock forward one fixed step; call it once per simulated frame
+func (w *World) Advance() {
+	w.Tick++
+	if ti, ok := w.Input.(TickedInput); ok {
+		ti.Poll()
+	}
+}
+
+// Reseed restarts the gameplay RNG; together with the input stream it fully determines a run
+func (w *World) Reseed(seed int64) {
+	w.RNG.Seed(seed)
+}
+
+// Seconds is the simulated time since the level started
+func (w *World) Seconds() float64 {
+	return float64(w.Tick) * core.TimeStep
+}
+
+// TicksFor converts a duration in seconds to whole sim ticks, rounding to the nearest step
+func TicksFor(seconds float64) uint64 {
+	if seconds <= 0 { return 0 }
+	return uint64(seconds/core.TimeStep + 0.5)
 }
 
 func (w *World) CreateEntity() core.Entity {
+	if n := len(w.free); n > 0 {
+		// DestroyEntity already cleared the slot and moved it to a new generation
+		id := w.free[n-1]
+		w.free = w.free[:n-1]
+		w.activate(id)
+		return id
+	}
+
 	id := w.nextID
 	w.nextID++
+	w.activeAt = append(w.activeAt, -1)
 	
-	w.Transforms = append(w.Transforms, nil)
-	w.Physics = append(w.Physics, nil)
-	w.Renders = append(w.Renders, nil)
-	w.AIs = append(w.AIs, nil)
-	w.Tags = append(w.Tags, nil)
-	w.GravityWells = append(w.GravityWells, nil)
-	w.InputControlleds = append(w.InputControlleds, nil)
-	w.Walls = append(w.Walls, nil)
-	w.ProjectileEmitters = append(w.ProjectileEmitters, nil)
-	w.Lifetimes = append(w.Lifetimes, nil)
-	
-	w.ActiveEntities = append(w.ActiveEntities, id)
+	if int(id) == len(w.generations) {
+		if id >= 1<<core.HandleIndexBits { panic("world: out of entity slots") }
+		w.generations = append(w.generations, 1)
+	}
+
+	w.activate(id)
 	return id
 }
 
-func (w *World) AddToActiveWalls(id core.Entity) {
-	w.ActiveWalls = append(w.ActiveWalls, id)
+// activate puts a new or recycled slot on the active list
+func (w *World) activate(id core.Entity) {
+	w.activeAt[id] = len(w.ActiveEntities)
+	w.ActiveEntities = append(w.ActiveEntities, id)
+}
+
+// Handle names the live entity id so it can be told apart from whatever reuses its slot later
+func (w *World) Handle(id core.Entity) core.Handle {
+	if id < 0 || int(id) >= len(w.activeAt) { return core.Handle{} }
+	return core.Handle{ID: id, Gen: w.generations[id]}
+}
+
+// Alive reports whether h still names the entity it was taken from: not destroyed, and not left
+// over from before a Reset
+func (w *World) Alive(h core.Handle) bool {
+	return h.Gen != 0 && h.ID >= 0 && int(h.ID) < len(w.activeAt) && w.generations[h.ID] == h.Gen
+}
+
+// Resolve is the entity h names, if it is still alive
+func (w *World) Resolve(h core.Handle) (core.Entity, bool) {
+	return h.ID, w.Alive(h)
+}
+
+// Issued reports whether h could have come from this world, alive or not; zero is never issued
+func (w *World) Issued(h core.Handle) bool {
+	return h.Gen != 0 && h.ID >= 0 && int(h.ID) < len(w.generations) && h.Gen <= w.generations[h.ID]
+}
+
+// retire moves id's slot on to its next generation, invalidating every handle to it
+func (w *World) retire(id core.Entity) {
+	if w.generations[id]++; w.generations[id] == 0 { w.generations[id] = 1 }
+}
+
+// Slots is how many entity slots the world has handed out, live or waiting for reuse
+func (w *World) Slots() int { return len(w.activeAt) }
+
+// Exists reports whether id is in range and not yet destroyed; every entity carries a Transform while alive
+func (w *World) Exists(id core.Entity) bool {
+	return w.Transforms.Has(id)
 }
 
 func (w *World) DestroyEntity(id core.Entity) {
 	idx := int(id)
-	if idx >= len(w.Transforms) { return }
-	
-	w.Transforms[idx], w.Physics[idx], w.Renders[idx] = nil, nil, nil
-	w.AIs[idx], w.Tags[idx], w.GravityWells[idx] = nil, nil, nil
-	w.InputControlleds[idx], w.Walls[idx] = nil, nil
-	w.ProjectileEmitters[idx], w.Lifetimes[idx] = nil, nil
-
-	for i, eid := range w.ActiveEntities {
-		if eid == id {
-			w.ActiveEntities[i] = w.ActiveEntities[len(w.ActiveEntities)-1]
-			w.ActiveEntities = w.ActiveEntities[:len(w.ActiveEntities)-1]
-			break
-		}
-	}
-	for i, eid := range w.ActiveWalls {
-		if eid == id {
-			w.ActiveWalls[i] = w.ActiveWalls[len(w.ActiveWalls)-1]
-			w.ActiveWalls = w.ActiveWalls[:len(w.ActiveWalls)-1]
-			break
-		}
+	// A slot already on the free list must not be handed out twice
+	if idx < 0 || idx >= len(w.activeAt) || w.activeAt[idx] < 0 { return }
+	w.retire(id)
+
+	for _, s := range w.stores {
+		s.Remove(id)
 	}
+
+	// Swap-remove from the active list, then hand the slot to the next CreateEntity
+	i, last := w.activeAt[idx], len(w.ActiveEntities)-1
+	moved := w.ActiveEntities[last]
+	w.ActiveEntities[i], w.activeAt[moved] = moved, i
+	w.ActiveEntities, w.activeAt[idx] = w.ActiveEntities[:last], -1
+	w.free = append(w.free, id)
 }
 
 func (w *World) UpdateGrid() {
-	for x := 0; x < 13; x++ {
-		for y := 0; y < 8; y++ {
+	for x := 0; x < GridCols; x++ {
+		for y := 0; y < GridRows; y++ {
 			w.Grid[x][y] = w.Grid[x][y][:0]
 		}
 	}
 
-	for _, id := range w.ActiveWalls {
-		trans := w.Transforms[id]
-		if trans == nil { continue }
+	for id := range Query(w.Walls, w.Transforms) {
+		trans := w.Transforms.Get(id)
 		
-		gx, gy := int(trans.Position.X/100), int(trans.Position.Y/100)
-		if gx >= 0 && gx < 13 && gy >= 0 && gy < 8 {
+		gx, gy := int(trans.Position.X/GridCell), int(trans.Position.Y/GridCell)
+		if gx >= 0 && gx < GridCols && gy >= 0 && gy < GridRows {
 			w.Grid[gx][gy] = append(w.Grid[gx][gy], id)
 		}
 	}
@@ -0,0 +1,108 @@
+package world
+
+import (
+	"slices"
+	"testing"
+
+	"beautifulmess/pkg/core"
+)
+
+func TestHandles(t *testing.T) {
+	w := NewHeadlessWorld()
+	kept := addWall(w, 100, 100, false)
+	shot := addWall(w, 200, 100, false)
+	keptH, shotH := w.Handle(kept), w.Handle(shot)
+	w.DestroyEntity(shot)
+
+	// A level load hands out the same indices again
+	w.Reset()
+	reloaded := addWall(w, 100, 100, false)
+	reloadedH := w.Handle(reloaded)
+
+	tests := []struct {
+		name       string
+		h          core.Handle
+		wantAlive  bool
+		wantIssued bool
+	}{
+		{"Live", reloadedH, true, true},
+		{"Destroyed", shotH, false, true},
+		{"Same index before a reset", keptH, false, true},
+		{"Zero handle", core.Handle{}, false, false},
+		{"Never handed out", core.Handle{ID: 50, Gen: 1}, false, false},
+		{"Future generation", core.Handle{ID: reloaded, Gen: reloadedH.Gen + 1}, false, false},
+		{"Negative", core.Handle{ID: -1, Gen: 1}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Alive(tt.h); got != tt.wantAlive {
+				t.Errorf("Alive(%+v) = %v, want %v", tt.h, got, tt.wantAlive)
+			}
+			if got := w.Issued(tt.h); got != tt.wantIssued {
+				t.Errorf("Issued(%+v) = %v, want %v", tt.h, got, tt.wantIssued)
+			}
+		})
+	}
+
+	if reloaded != kept {
+		t.Fatalf("reset world handed out index %d first, want %d; the reset case tests nothing", reloaded, kept)
+	}
+	if id, ok := w.Resolve(reloadedH); !ok || id != reloaded {
+		t.Errorf("Resolve(%+v) = %d, %v, want %d, true", reloadedH, id, ok, reloaded)
+	}
+}
+
+func TestHandlePackSurvivesLuaNumbers(t *testing.T) {
+	for _, h := range []core.Handle{
+		{ID: 0, Gen: 1},
+		{ID: 1<<core.HandleIndexBits - 1, Gen: 1},
+		{ID: 7, Gen: 1<<32 - 1},
+	} {
+		// Scripts hold handles as float64s
+		if got := core.UnpackHandle(int64(float64(h.Pack()))); got != h {
+			t.Errorf("UnpackHandle(Pack(%+v)) = %+v", h, got)
+		}
+	}
+}
+
+func TestDestroyEntityRecyclesSlots(t *testing.T) {
+	w := NewHeadlessWorld()
+	a := addWall(w, 100, 100, false)
+	b := addWall(w, 200, 100, false)
+	c := addWall(w, 300, 100, false)
+	bH := w.Handle(b)
+
+	w.DestroyEntity(b)
+	w.DestroyEntity(b) // Twice must not put the slot on the free list twice
+	wantActive := map[core.Entity]bool{a: true, c: true}
+	walls := slices.Collect(Query(w.Walls))
+	for name, list := range map[string][]core.Entity{"ActiveEntities": w.ActiveEntities, "Query(Walls)": walls} {
+		if len(list) != len(wantActive) {
+			t.Errorf("%s = %v, want %d entries", name, list, len(wantActive))
+		}
+		for _, id := range list {
+			if !wantActive[id] {
+				t.Errorf("%s = %v, holds %d", name, list, id)
+			}
+		}
+	}
+
+	d := w.CreateEntity()
+	e := w.CreateEntity()
+	if d != b {
+		t.Errorf("CreateEntity() = %d, want the freed slot %d", d, b)
+	}
+	if e == d || w.Slots() != 4 {
+		t.Errorf("second CreateEntity() = %d with %d slots, want a fresh slot", e, w.Slots())
+	}
+	if w.Alive(bH) || !w.Alive(w.Handle(d)) {
+		t.Errorf("Alive(old handle) = %v, Alive(new handle) = %v, want false, true", w.Alive(bH), w.Alive(w.Handle(d)))
+	}
+	if w.Walls.Get(d) != nil {
+		t.Errorf("recycled slot kept wall %+v", w.Walls.Get(d))
+	}
+	if len(w.ActiveEntities) != 4 || w.Walls.Len() != 2 {
+		t.Errorf("ActiveEntities = %v, %d walls, want 4 and 2", w.ActiveEntities, w.Walls.Len())
+	}
+}
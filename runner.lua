@@ -1,6 +1,116 @@
 runner = {}
 
-function runner.update_state(id, mem_x, mem_y, mem_radius, well_x, well_y)
-    -- Input is processed by the Go-side SystemInput to ensure high-fidelity responsiveness
-    -- This script is a placeholder for entity-specific logic should the player model expand
-end
\ No newline at end of file
+-- Handling mirrors SystemInput so the bot drives the same ship a player does
+local BASE_SPEED, BOOST_SPEED = 7.5, 15.0
+local ACCEL, BOOST_ACCEL = 1.5, 4.5
+
+local SHOT_RANGE = 900       -- Bullets live two seconds at 8px a tick
+local AIM_SAMPLES = 36
+local SWEEP_TICKS = 4        -- Ticks a bank-shot sweep is spread over; traces are the expensive part
+local PUSH_DISTANCE = 40     -- How far behind the spectre to sit while herding it
+local CIRCLE_DISTANCE = 170  -- Outside the spectre's 120px threat range, so circling round does not spook it
+
+-- The bot only plays unattended, e.g. in the playtest harness; with a human at the controls it stands down
+function runner.init(self, id)
+    self.aim = nil
+    self.sweep = nil
+end
+
+-- The well the spectre is closest to is the one worth herding it into
+local function best_well(sx, sy)
+    local best, best_dist
+    for _, well in ipairs(get_wells()) do
+        local _, _, d = get_vec_between(sx, sy, well.x, well.y)
+        if not best or d - well.radius < best_dist then best, best_dist = well, d - well.radius end
+    end
+    return best
+end
+
+-- Picks a shot that reaches where the spectre will be, banking off walls when there is no clear line
+local function choose_aim(self, id, x, y, sx, sy)
+    local lead_x, lead_y = self.spec_vx or 0, self.spec_vy or 0
+    local _, _, dist = get_vec_between(x, y, sx, sy)
+    local t = dist / 8
+    local tx, ty = sx + lead_x * t, sy + lead_y * t
+
+    local dx, dy = get_vec_between(x, y, tx, ty)
+    local miss = trace_shot(x, y, dx, dy, tx, ty, SHOT_RANGE, 0)
+    if miss < 15 then
+        self.sweep = nil
+        return math.atan2(dy, dx)
+    end
+
+    -- No clear line: sweep the compass for a bank shot a slice a tick, so no single tick pays for
+    -- every trace, and keep the previous aim until the sweep is done
+    local sweep = self.sweep or { next = 0 }
+    self.sweep = sweep
+    for _ = 1, AIM_SAMPLES / SWEEP_TICKS do
+        local a = sweep.next / AIM_SAMPLES * 2 * math.pi
+        local m = trace_shot(x, y, math.cos(a), math.sin(a), tx, ty, SHOT_RANGE, 2)
+        if not sweep.best or m < sweep.miss then sweep.best, sweep.miss = a, m end
+        sweep.next = sweep.next + 1
+    end
+    if sweep.next < AIM_SAMPLES then return self.aim end
+    self.sweep = nil
+    if sweep.miss < 20 then return sweep.best end
+    return nil
+end
+
+function runner.update_state(self, id, mem_x, mem_y, mem_radius, well_x, well_y)
+    if is_player_controlled(id) then return end
+
+    local x, y, vx, vy = get_self(id)
+    local sx, sy = get_target(id)
+    if not sx then return end
+    -- Tracking the spectre's motion lets shots lead it
+    if self.last_sx then
+        local ddx, ddy, d = get_vec_between(self.last_sx, self.last_sy, sx, sy)
+        if d < 50 then self.spec_vx, self.spec_vy = ddx * d, ddy * d end
+    end
+    self.last_sx, self.last_sy = sx, sy
+
+    local well = best_well(sx, sy)
+    local gx, gy = sx, sy
+    if well then
+        -- out_x, out_y points from the well through the spectre: the side to push from
+        local out_x, out_y, spec_well_dist = get_vec_between(well.x, well.y, sx, sy)
+        local rx, ry = get_vec_between(sx, sy, x, y)
+        if spec_well_dist < well.radius + 40 then
+            -- Nearly in; close the last gap and pin it
+            gx, gy = sx, sy
+        elseif rx * out_x + ry * out_y > 0.7 then
+            -- Lined up behind it: lean in so it flees toward the well
+            gx, gy = sx + out_x * PUSH_DISTANCE, sy + out_y * PUSH_DISTANCE
+        else
+            -- Circle round at a distance, a step at a time, to reach the far side without chasing it off
+            local here, there = math.atan2(ry, rx), math.atan2(out_y, out_x)
+            local diff = math.atan2(math.sin(there - here), math.cos(there - here))
+            local a = here + math.max(-1, math.min(1, diff))
+            gx, gy = sx + math.cos(a) * CIRCLE_DISTANCE, sy + math.sin(a) * CIRCLE_DISTANCE
+        end
+    end
+
+    -- No detours: a wall only stalls a ship that keeps thrusting into it, and detouring round
+    -- closed outlines (chapter 3's face) never reaches the wells inside
+    local dx, dy, gdist = get_vec_to(id, gx, gy)
+
+    local boost = gdist > 250
+    local top = boost and BOOST_SPEED or BASE_SPEED
+    set_max_speed(id, top)
+    -- Steering toward a target velocity brakes on arrival instead of orbiting the goal
+    local want = math.min(top, gdist * 0.15)
+    local ax, ay = dx * want - vx, dy * want - vy
+    local len = math.sqrt(ax * ax + ay * ay)
+    if len > 1 then ax, ay = ax / len, ay / len end
+    local accel = boost and BOOST_ACCEL or ACCEL
+    apply_force(id, ax * accel, ay * accel)
+
+    -- The emitter fires along the ship's rotation, so aiming is just facing
+    self.aim = choose_aim(self, id, x, y, sx, sy)
+    if self.aim then
+        set_rotation(id, self.aim)
+    elseif dx ~= 0 or dy ~= 0 then
+        -- With nothing to hit, face backwards so the recoil adds to the chase
+        set_rotation(id, math.atan2(-dy, -dx))
+    end
+end
@@ -1,45 +1,90 @@
 spectre = {}
 
--- Utility-based state machine constants
+-- State constants; the behaviour tree picks one and update_state turns it into forces
 local STATE_CRUISE = 0
 local STATE_SPRINT = 1
 local STATE_JINK   = 2
 local STATE_RECOVER = 3
+local STATE_ORBIT  = 4
+local STATE_COVER  = 5
 
-local current_state = STATE_CRUISE
-local state_timer = 0
-local stamina = 100.0
-local max_stamina = 100.0
-local jink_dir = 1
+local MAX_STAMINA = 100.0
+local DEFAULT_PERSONALITY = "wary"
 
-function spectre.update_state(id, mem_x, mem_y, mem_radius, well_x, well_y)
+-- Leaves are the verbs the trees in behaviours/ are written with; each runs with the spectre's self table
+spectre.leaves = {}
+
+function spectre.leaves.cruise(self, id) self.state = STATE_CRUISE end
+function spectre.leaves.sprint(self, id) self.state = STATE_SPRINT end
+-- Whether the spectre ran itself dry is fixed when the rest starts; trees can rest longer for it
+function spectre.leaves.recover(self, id)
+    self.state = STATE_RECOVER
+    self.exhausted = self.stamina <= 0
+end
+function spectre.leaves.orbit(self, id) self.state = STATE_ORBIT end
+
+function spectre.leaves.jink(self, id)
+    -- Active counter-force maneuvers prevent the player from easily maintaining contact
+    self.state = STATE_JINK
+    self.jink_dir = (math.random() < 0.5) and 1 or -1
+    play_sound("spectre_dash")
+end
+
+-- True while nothing stands between the spectre and the runner
+function spectre.leaves.exposed(self, id)
+    local x, y = get_self(id)
+    local dx, dy, d = get_vec_to(id, self.opp_x, self.opp_y)
+    return not raycast(x, y, dx, dy, d)
+end
+
+-- Picks the nearby wall that best shields the spectre and remembers the spot behind it
+function spectre.leaves.find_cover(self, id)
+    local x, y = get_self(id)
+    local best
+    for _, wall in ipairs(get_walls_near(x, y, 250)) do
+        -- Walls come nearest first; only one closer than the runner can end up between them
+        if wall.dist < self.dist then best = wall; break end
+    end
+    if not best then return false end
+    -- The hiding spot sits on the far side of the wall from the runner
+    local away_x, away_y = best.x - self.opp_x, best.y - self.opp_y
+    local len = math.max(0.01, math.sqrt(away_x * away_x + away_y * away_y))
+    self.cover_x, self.cover_y = best.x + away_x / len * 25, best.y + away_y / len * 25
+end
+
+function spectre.leaves.take_cover(self, id)
+    self.state = STATE_COVER
+    local _, _, d = get_vec_to(id, self.cover_x, self.cover_y)
+    if d > 15 then return "running" end
+end
+
+-- Each spectre owns its own brain; self is a fresh table per entity, discarded when it is destroyed
+function spectre.init(self, id)
+    self.state = STATE_CRUISE
+    self.stamina = MAX_STAMINA
+    self.jink_dir = 1
+    self.threatened = false
+
+    -- Chapters choose a personality; each is a tree file named after it
+    local personality = get_personality(id)
+    if personality == "" then personality = DEFAULT_PERSONALITY end
+    self.tree = bt_new("spectre_" .. personality, spectre.leaves)
+end
+
+function spectre.update_state(self, id, mem_x, mem_y, mem_radius, well_x, well_y)
     local _, _, my_vx, my_vy = get_self(id)
     local opp_x, opp_y = get_target(id)
     local to_opp_x, to_opp_y, dist = get_vec_to(id, opp_x, opp_y)
-    
-    state_timer = state_timer - 1
-    
+
     -- Stamina regeneration prevents infinite sprinting and encourages tactical retreats
-    if current_state ~= STATE_SPRINT then stamina = math.min(max_stamina, stamina + 0.5) end
-
-    -- Threat-response logic triggers evasion when the runner enters the spectre's personal space
-    if dist < 120 and current_state == STATE_CRUISE then
-        if stamina > 30 then
-            -- Active counter-force maneuvers prevent the player from easily maintaining contact
-            current_state = STATE_JINK; state_timer = 15; jink_dir = (math.random()<0.5) and 1 or -1
-            play_sound("spectre_dash")
-        else
-            current_state = STATE_RECOVER; state_timer = 40
-        end
-    end
-    
-    -- State transitions are timer-based to ensure rhythmic movement cycles
-    if state_timer <= 0 then
-        if current_state == STATE_SPRINT then current_state = STATE_RECOVER; state_timer = 30
-        elseif current_state == STATE_JINK then current_state = STATE_SPRINT; state_timer = 40
-        elseif current_state == STATE_RECOVER then current_state = STATE_CRUISE end
-    end
-    
+    if self.state ~= STATE_SPRINT then self.stamina = math.min(MAX_STAMINA, self.stamina + 0.5) end
+    -- Perception goes on self, which doubles as the tree's blackboard
+    self.threatened = dist < 120
+    self.dist, self.opp_x, self.opp_y = dist, opp_x, opp_y
+
+    -- Passing the leaves each tick lets a hot reload swap them under a running tree
+    bt_tick(self.tree, self, id, spectre.leaves)
+
     -- Resistance forces near memory nodes simulate the narrative 'struggle' against re-assimilation
     local to_mem_x, to_mem_y, mem_dist = get_vec_to(id, mem_x, mem_y)
     if mem_dist < mem_radius then
@@ -50,39 +95,48 @@ function spectre.update_state(id, mem_x, mem_y, mem_radius, well_x, well_y)
     end
 
     local fx, fy = 0, 0
-    
-    if current_state == STATE_CRUISE then
+
+    if self.state == STATE_CRUISE then
         set_max_speed(id, 4.0)
         fx, fy = -to_opp_x * 0.5, -to_opp_y * 0.5
-        
+
         -- Singularity avoidance simulates active engine compensation against gravitational pull
         local to_well_x, to_well_y, well_dist = get_vec_to(id, well_x, well_y)
         if well_dist < 400 then
              -- Applying a counter-force away from the well increases the effort required to trap the entity
              fx, fy = fx - (to_well_x * 1.2), fy - (to_well_y * 1.2)
         end
-        
-    elseif current_state == STATE_SPRINT then
+
+    elseif self.state == STATE_SPRINT then
         set_max_speed(id, 9.0)
-        stamina = stamina - 2.0
+        self.stamina = self.stamina - 2.0
         fx, fy = -to_opp_x * 2.0, -to_opp_y * 2.0
-        if stamina <= 0 then current_state = STATE_RECOVER; state_timer = 60 end
-        
-    elseif current_state == STATE_JINK then
+
+    elseif self.state == STATE_JINK then
         set_max_speed(id, 12.0)
-        stamina = stamina - 1.0
+        self.stamina = self.stamina - 1.0
         -- Perpendicular vectors create lateral movement to break target locks
-        fx, fy = -to_opp_y * jink_dir * 3.0, to_opp_x * jink_dir * 3.0
-        
-    elseif current_state == STATE_RECOVER then
+        fx, fy = -to_opp_y * self.jink_dir * 3.0, to_opp_x * self.jink_dir * 3.0
+
+    elseif self.state == STATE_RECOVER then
         set_max_speed(id, 3.0)
         fx, fy = -to_opp_x * 0.8, -to_opp_y * 0.8
+
+    elseif self.state == STATE_ORBIT then
+        -- Circling just out of reach teases the runner without fleeing outright
+        set_max_speed(id, 6.0)
+        fx, fy = -to_opp_y * self.jink_dir * 1.2 - to_opp_x * 0.3, to_opp_x * self.jink_dir * 1.2 - to_opp_y * 0.3
+
+    elseif self.state == STATE_COVER then
+        set_max_speed(id, 7.0)
+        local to_cover_x, to_cover_y = get_vec_to(id, self.cover_x, self.cover_y)
+        fx, fy = to_cover_x * 1.5, to_cover_y * 1.5
     end
-    
+
     -- Velocity damping prevents infinite drifting in the void
     if math.abs(fx) < 0.1 and math.abs(fy) < 0.1 then
         fx, fy = my_vx * 0.1, my_vy * 0.1
     end
 
     apply_force(id, fx, fy)
-end
\ No newline at end of file
+end